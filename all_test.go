@@ -4,6 +4,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -11,9 +12,10 @@ import (
 func getTestClient(t *testing.T) Client {
 	const uuid = "dummy-uuid"
 	const secret = "dummy-secret"
-	client, err := NewClient(uuid, secret)
+	c, err := NewClient(uuid, secret)
 	assert.Nil(t, err, "NewClient error")
-	return client
+	c.(*client).transactionPollInterval = time.Millisecond
+	return c
 }
 
 func getTestDataString(t *testing.T, filename string) string {
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -38,6 +39,13 @@ type client struct {
 	hc         *http.Client
 	userAgent  string
 	debugLog   LogFunc
+
+	transactionPollInterval time.Duration
+	regionCache             regionCache
+
+	orderCostPolicy OrderCostPolicy
+	ordersMu        sync.Mutex
+	pendingOrders   map[string]pendingOrder
 }
 
 type LogFunc func(message string)
@@ -57,14 +65,24 @@ type Client interface {
 	DeviceUpdateFirewall(ctx context.Context, deviceId int, policies []FirewallPolicy) error
 
 	TransactionCreate(ctx context.Context, transactionType string, objectType string, objectId string, confirm bool) (*Transaction, error)
+	TransactionCreateWithData(ctx context.Context, transactionType string, objectType string, objectId string, confirm bool, data any) (*Transaction, error)
 	TransactionDeviceStartup(ctx context.Context, deviceId string, data TransactionStartupData) (*Transaction, error)
 	TransactionGet(ctx context.Context, transactionId string) (*Transaction, error)
 	TransactionGetAll(ctx context.Context, filter TransactionFilter) ([]Transaction, int, error)
+	TransactionWait(ctx context.Context, transactionId string) (*Transaction, error)
+	TransactionConfirm(ctx context.Context, transactionId string) (*Transaction, error)
+	TransactionCancel(ctx context.Context, transactionId string) error
+	TransactionCreateWithApproval(ctx context.Context, transactionType string, objectType string, objectId string, data any, approve TransactionApprover) (*Transaction, error)
 
 	LoadBalancer() LoadBalancerClient
 	Device() DeviceClient
+	Region() RegionClient
+	Network() NetworkClient
 
 	SetDebugLog(logFunc LogFunc)
+	// SetOrderCostPolicy sets the policy OrderConfirm checks before confirming an order, e.g. a BudgetPolicy.
+	// A nil policy allows every order.
+	SetOrderCostPolicy(policy OrderCostPolicy)
 }
 
 var _ Client = (*client)(nil)
@@ -72,6 +90,8 @@ var _ Client = (*client)(nil)
 const (
 	defaultBaseUrl    = "https://api.rackcorp.net/api/"
 	defaultApiVersion = "v2.9"
+
+	defaultTransactionPollInterval = 5 * time.Second
 )
 
 func NewClient(uuid string, secret string) (Client, error) {
@@ -93,6 +113,8 @@ func NewClient(uuid string, secret string) (Client, error) {
 		},
 		userAgent: fmt.Sprintf("rackcorpapi/1.0 golang/%s", runtime.Version()),
 		debugLog:  noopLog,
+
+		transactionPollInterval: defaultTransactionPollInterval,
 	}, nil
 }
 
@@ -112,6 +134,14 @@ func (c *client) LoadBalancer() LoadBalancerClient {
 	return &loadBalancerClient{c: c}
 }
 
+func (c *client) Region() RegionClient {
+	return &regionClient{c: c}
+}
+
+func (c *client) Network() NetworkClient {
+	return &networkClient{c: c}
+}
+
 func (c *client) SetDebugLog(logFunc LogFunc) {
 	if logFunc == nil {
 		c.debugLog = noopLog
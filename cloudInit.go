@@ -0,0 +1,350 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/netip"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// cloudInitBuilderMaxDataBytes is the largest user data, meta data or network config CloudInitBuilder will
+// produce. It is a guard against runaway generated documents, not a documented Rackcorp limit, so it is
+// not applied to cloud-init data passed to TransactionDeviceStartup directly.
+const cloudInitBuilderMaxDataBytes = 64 * 1024
+
+const (
+	CloudInitContentTypeCloudConfig = "text/cloud-config"
+	CloudInitContentTypeShellScript = "text/x-shellscript"
+	CloudInitContentTypeBoothook    = "text/cloud-boothook"
+)
+
+type CloudInitUser struct {
+	Name              string   `yaml:"name"`
+	Gecos             string   `yaml:"gecos,omitempty"`
+	Groups            []string `yaml:"groups,omitempty,flow"`
+	Shell             string   `yaml:"shell,omitempty"`
+	Sudo              string   `yaml:"sudo,omitempty"`
+	LockPasswd        bool     `yaml:"lock_passwd"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+}
+
+type CloudInitFile struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content"`
+	Owner       string `yaml:"owner,omitempty"`
+	Permissions string `yaml:"permissions,omitempty"`
+	Encoding    string `yaml:"encoding,omitempty"`
+	Append      bool   `yaml:"append,omitempty"`
+}
+
+// CloudInitPart is an extra part of a MIME multipart user data, e.g. a shell script.
+type CloudInitPart struct {
+	Filename    string
+	ContentType string
+	Content     string
+}
+
+type cloudInitConfig struct {
+	Hostname       string          `yaml:"hostname,omitempty"`
+	FQDN           string          `yaml:"fqdn,omitempty"`
+	Timezone       string          `yaml:"timezone,omitempty"`
+	Users          []any           `yaml:"users,omitempty"`
+	PackageUpdate  bool            `yaml:"package_update,omitempty"`
+	PackageUpgrade bool            `yaml:"package_upgrade,omitempty"`
+	Packages       []string        `yaml:"packages,omitempty"`
+	WriteFiles     []CloudInitFile `yaml:"write_files,omitempty"`
+	RunCmd         []string        `yaml:"runcmd,omitempty"`
+}
+
+type cloudInitMetaData struct {
+	InstanceID    string `yaml:"instance-id,omitempty"`
+	LocalHostname string `yaml:"local-hostname,omitempty"`
+}
+
+type cloudInitNetworkConfig struct {
+	Version   int                                 `yaml:"version"`
+	Ethernets map[string]cloudInitNetworkEthernet `yaml:"ethernets"`
+}
+
+type cloudInitNetworkEthernet struct {
+	DHCP4       bool                         `yaml:"dhcp4"`
+	DHCP6       bool                         `yaml:"dhcp6"`
+	Addresses   []string                     `yaml:"addresses,omitempty"`
+	Routes      []cloudInitNetworkRoute      `yaml:"routes,omitempty"`
+	Nameservers *cloudInitNetworkNameservers `yaml:"nameservers,omitempty"`
+}
+
+type cloudInitNetworkRoute struct {
+	To  string `yaml:"to"`
+	Via string `yaml:"via"`
+}
+
+type cloudInitNetworkNameservers struct {
+	Addresses []string `yaml:"addresses,flow"`
+	Search    []string `yaml:"search,omitempty,flow"`
+}
+
+// CloudInitBuilder builds a TransactionStartupCloudInit from typed configuration.
+type CloudInitBuilder struct {
+	config      cloudInitConfig
+	metaData    cloudInitMetaData
+	parts       []CloudInitPart
+	ips         []DeviceIP
+	nameservers []netip.Addr
+	searchPaths []string
+	errs        []error
+}
+
+func NewCloudInitBuilder() *CloudInitBuilder {
+	return &CloudInitBuilder{}
+}
+
+func (b *CloudInitBuilder) Hostname(hostname string, fqdn string) *CloudInitBuilder {
+	b.config.Hostname = hostname
+	b.config.FQDN = fqdn
+	b.metaData.LocalHostname = hostname
+	return b
+}
+
+func (b *CloudInitBuilder) InstanceID(instanceID string) *CloudInitBuilder {
+	b.metaData.InstanceID = instanceID
+	return b
+}
+
+func (b *CloudInitBuilder) Timezone(timezone string) *CloudInitBuilder {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid timezone %q: %w", timezone, err))
+	}
+	b.config.Timezone = timezone
+	return b
+}
+
+func (b *CloudInitBuilder) AddUser(user CloudInitUser) *CloudInitBuilder {
+	if user.Name == "" {
+		b.errs = append(b.errs, errors.New("cloud-init user name is required"))
+	}
+	if len(b.config.Users) == 0 {
+		b.config.Users = append(b.config.Users, "default")
+	}
+	b.config.Users = append(b.config.Users, user)
+	return b
+}
+
+func (b *CloudInitBuilder) AddPackages(packages ...string) *CloudInitBuilder {
+	b.config.Packages = append(b.config.Packages, packages...)
+	return b
+}
+
+func (b *CloudInitBuilder) PackageUpgrade(update bool, upgrade bool) *CloudInitBuilder {
+	b.config.PackageUpdate = update
+	b.config.PackageUpgrade = upgrade
+	return b
+}
+
+func (b *CloudInitBuilder) AddFile(file CloudInitFile) *CloudInitBuilder {
+	if file.Path == "" {
+		b.errs = append(b.errs, errors.New("cloud-init write_files path is required"))
+	}
+	b.config.WriteFiles = append(b.config.WriteFiles, file)
+	return b
+}
+
+func (b *CloudInitBuilder) AddRunCmd(commands ...string) *CloudInitBuilder {
+	b.config.RunCmd = append(b.config.RunCmd, commands...)
+	return b
+}
+
+// AddPart adds an extra part to the user data, which is then rendered as MIME multipart.
+func (b *CloudInitBuilder) AddPart(part CloudInitPart) *CloudInitBuilder {
+	if part.ContentType == "" {
+		b.errs = append(b.errs, fmt.Errorf("cloud-init part %q content type is required", part.Filename))
+	}
+	b.parts = append(b.parts, part)
+	return b
+}
+
+// StaticNetwork renders a network-config v2 with static addresses for the given device IPs,
+// e.g. Device.IPs. The IPs are grouped by port, and as PortID is a port record ID rather than an
+// interface index the ports are named eth0, eth1, ... in order of PortID.
+func (b *CloudInitBuilder) StaticNetwork(ips []DeviceIP, nameservers []netip.Addr, searchPaths ...string) *CloudInitBuilder {
+	for _, ip := range ips {
+		if !ip.Address.IsValid() || !ip.Network.IsValid() {
+			b.errs = append(b.errs, fmt.Errorf("device IP %s has no network", ip.Address))
+		}
+	}
+	b.ips = ips
+	b.nameservers = nameservers
+	b.searchPaths = searchPaths
+	return b
+}
+
+func (b *CloudInitBuilder) Build() (TransactionStartupCloudInit, error) {
+	var result TransactionStartupCloudInit
+	if len(b.errs) > 0 {
+		return result, errors.Join(b.errs...)
+	}
+
+	userData, err := b.renderUserData()
+	if err != nil {
+		return result, err
+	}
+	result.UserData = userData
+
+	if b.metaData != (cloudInitMetaData{}) {
+		metaData, err := yaml.Marshal(b.metaData)
+		if err != nil {
+			return result, fmt.Errorf("failed to YAML encode cloud-init meta data: %w", err)
+		}
+		result.MetaData = string(metaData)
+	}
+
+	if len(b.ips) > 0 {
+		networkConfig, err := yaml.Marshal(b.networkConfig())
+		if err != nil {
+			return result, fmt.Errorf("failed to YAML encode cloud-init network config: %w", err)
+		}
+		result.NetworkConfig = string(networkConfig)
+	}
+
+	var errs []error
+	for _, doc := range result.documents() {
+		if len(doc.data) > cloudInitBuilderMaxDataBytes {
+			errs = append(errs, fmt.Errorf("cloud-init %s is %d bytes, exceeding the builder limit of %d bytes", doc.name, len(doc.data), cloudInitBuilderMaxDataBytes))
+		}
+	}
+	if len(errs) > 0 {
+		return result, errors.Join(errs...)
+	}
+	return result, result.Validate()
+}
+
+func (b *CloudInitBuilder) renderUserData() (string, error) {
+	config, err := yaml.Marshal(b.config)
+	if err != nil {
+		return "", fmt.Errorf("failed to YAML encode cloud-init user data: %w", err)
+	}
+	cloudConfig := "#cloud-config\n" + string(config)
+
+	if len(b.parts) == 0 {
+		return cloudConfig, nil
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=\"%s\"\nMIME-Version: 1.0\n\n", mw.Boundary())
+
+	parts := append([]CloudInitPart{{
+		Filename:    "cloud-config.yaml",
+		ContentType: CloudInitContentTypeCloudConfig,
+		Content:     cloudConfig,
+	}}, b.parts...)
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.ContentType+"; charset=\"utf-8\"")
+		header.Set("MIME-Version", "1.0")
+		header.Set("Content-Transfer-Encoding", "7bit")
+		if part.Filename != "" {
+			header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", part.Filename))
+		}
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			return "", fmt.Errorf("failed to create cloud-init MIME part: %w", err)
+		}
+		if _, err := w.Write([]byte(part.Content)); err != nil {
+			return "", fmt.Errorf("failed to write cloud-init MIME part: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("failed to close cloud-init MIME multipart: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+func (b *CloudInitBuilder) networkConfig() cloudInitNetworkConfig {
+	config := cloudInitNetworkConfig{
+		Version:   2,
+		Ethernets: map[string]cloudInitNetworkEthernet{},
+	}
+
+	ports := map[int][]DeviceIP{}
+	for _, ip := range b.ips {
+		ports[ip.PortID] = append(ports[ip.PortID], ip)
+	}
+
+	portIds := make([]int, 0, len(ports))
+	for portId := range ports {
+		portIds = append(portIds, portId)
+	}
+	sort.Ints(portIds)
+
+	for i, portId := range portIds {
+		var ethernet cloudInitNetworkEthernet
+		var gateway4, gateway6 netip.Addr
+		for _, ip := range ports[portId] {
+			ethernet.Addresses = append(ethernet.Addresses, netip.PrefixFrom(ip.Address, ip.Network.Bits()).String())
+			if !ip.Gateway.IsValid() {
+				continue
+			}
+			if ip.Gateway.Is4() && (!gateway4.IsValid() || ip.Primary) {
+				gateway4 = ip.Gateway
+			}
+			if ip.Gateway.Is6() && (!gateway6.IsValid() || ip.Primary) {
+				gateway6 = ip.Gateway
+			}
+		}
+		if gateway4.IsValid() {
+			ethernet.Routes = append(ethernet.Routes, cloudInitNetworkRoute{To: "0.0.0.0/0", Via: gateway4.String()})
+		}
+		if gateway6.IsValid() {
+			ethernet.Routes = append(ethernet.Routes, cloudInitNetworkRoute{To: "::/0", Via: gateway6.String()})
+		}
+		if i == 0 && len(b.nameservers) > 0 {
+			ethernet.Nameservers = &cloudInitNetworkNameservers{
+				Search: b.searchPaths,
+			}
+			for _, ns := range b.nameservers {
+				ethernet.Nameservers.Addresses = append(ethernet.Nameservers.Addresses, ns.String())
+			}
+		}
+		config.Ethernets[fmt.Sprintf("eth%d", i)] = ethernet
+	}
+
+	return config
+}
+
+type cloudInitDocument struct {
+	name     string
+	data     string
+	yamlOnly bool
+}
+
+func (ci TransactionStartupCloudInit) documents() []cloudInitDocument {
+	return []cloudInitDocument{
+		{"user data", ci.UserData, false},
+		{"meta data", ci.MetaData, true},
+		{"network config", ci.NetworkConfig, true},
+	}
+}
+
+// Validate checks that the YAML cloud-init documents parse. It does not limit their size.
+func (ci TransactionStartupCloudInit) Validate() error {
+	var errs []error
+	for _, doc := range ci.documents() {
+		if !doc.yamlOnly && !strings.HasPrefix(doc.data, "#cloud-config") {
+			continue
+		}
+		var tmp any
+		if err := yaml.Unmarshal([]byte(doc.data), &tmp); err != nil {
+			errs = append(errs, fmt.Errorf("cloud-init %s is not valid YAML: %w", doc.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
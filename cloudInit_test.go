@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCloudInitBuilder(t *testing.T) {
+	ips := []DeviceIP{
+		{
+			Address: netip.MustParseAddr("192.0.2.123"),
+			Primary: true,
+			Network: netip.MustParsePrefix("192.0.2.0/24"),
+			Gateway: netip.MustParseAddr("192.0.2.1"),
+		},
+		{
+			Address: netip.MustParseAddr("2001:db8::10"),
+			Network: netip.MustParsePrefix("2001:db8::/64"),
+			Gateway: netip.MustParseAddr("2001:db8::1"),
+		},
+	}
+
+	cloudInit, err := NewCloudInitBuilder().
+		Hostname("web1", "web1.example.com").
+		InstanceID("5075").
+		Timezone("Australia/Sydney").
+		AddUser(CloudInitUser{
+			Name:              "deploy",
+			Groups:            []string{"sudo"},
+			Shell:             "/bin/bash",
+			SSHAuthorizedKeys: []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDummy deploy@example.com"},
+			LockPasswd:        true,
+		}).
+		AddPackages("nginx", "curl").
+		AddFile(CloudInitFile{
+			Path:        "/etc/motd",
+			Content:     "Welcome\nto web1\n",
+			Permissions: "0644",
+		}).
+		AddRunCmd("systemctl enable --now nginx").
+		StaticNetwork(ips, []netip.Addr{netip.MustParseAddr("192.0.2.8")}, "example.com").
+		Build()
+	require.NoError(t, err, "Build error")
+
+	require.True(t, strings.HasPrefix(cloudInit.UserData, "#cloud-config\n"), "UserData header")
+	var userData map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(cloudInit.UserData), &userData), "UserData YAML")
+	assert.Equal(t, "web1", userData["hostname"], "hostname")
+	assert.Equal(t, "Australia/Sydney", userData["timezone"], "timezone")
+	assert.Equal(t, []any{"nginx", "curl"}, userData["packages"], "packages")
+	assert.Equal(t, []any{"systemctl enable --now nginx"}, userData["runcmd"], "runcmd")
+	assert.Len(t, userData["users"], 2, "users")
+	assert.Len(t, userData["write_files"], 1, "write_files")
+
+	assert.Equal(t, "instance-id: \"5075\"\nlocal-hostname: web1\n", cloudInit.MetaData, "MetaData")
+
+	expectedNetworkConfig := `version: 2
+ethernets:
+  eth0:
+    dhcp4: false
+    dhcp6: false
+    addresses:
+    - 192.0.2.123/24
+    - 2001:db8::10/64
+    routes:
+    - to: 0.0.0.0/0
+      via: 192.0.2.1
+    - to: ::/0
+      via: 2001:db8::1
+    nameservers:
+      addresses: [192.0.2.8]
+      search: [example.com]
+`
+	assert.Equal(t, expectedNetworkConfig, cloudInit.NetworkConfig, "NetworkConfig")
+}
+
+func TestCloudInitBuilderNetworkPorts(t *testing.T) {
+	ips := []DeviceIP{
+		{
+			Address: netip.MustParseAddr("10.0.0.5"),
+			Network: netip.MustParsePrefix("10.0.0.0/24"),
+			PortID:  48214,
+		},
+		{
+			Address: netip.MustParseAddr("192.0.2.123"),
+			Primary: true,
+			Network: netip.MustParsePrefix("192.0.2.0/24"),
+			Gateway: netip.MustParseAddr("192.0.2.1"),
+			PortID:  48213,
+		},
+	}
+
+	cloudInit, err := NewCloudInitBuilder().StaticNetwork(ips, nil).Build()
+	require.NoError(t, err, "Build error")
+
+	var networkConfig cloudInitNetworkConfig
+	require.NoError(t, yaml.Unmarshal([]byte(cloudInit.NetworkConfig), &networkConfig), "NetworkConfig YAML")
+	require.Len(t, networkConfig.Ethernets, 2, "ethernets")
+	assert.Equal(t, []string{"192.0.2.123/24"}, networkConfig.Ethernets["eth0"].Addresses, "eth0 is the lowest port ID")
+	assert.Equal(t, []string{"10.0.0.5/24"}, networkConfig.Ethernets["eth1"].Addresses, "eth1")
+}
+
+func TestCloudInitBuilderMultipart(t *testing.T) {
+	cloudInit, err := NewCloudInitBuilder().
+		AddPackages("curl").
+		AddPart(CloudInitPart{
+			Filename:    "setup.sh",
+			ContentType: CloudInitContentTypeShellScript,
+			Content:     "#!/bin/sh\necho hello\n",
+		}).
+		Build()
+	require.NoError(t, err, "Build error")
+
+	assert.True(t, strings.HasPrefix(cloudInit.UserData, "Content-Type: multipart/mixed; boundary="), "UserData header")
+	assert.Contains(t, cloudInit.UserData, "Content-Type: text/cloud-config; charset=\"utf-8\"", "cloud-config part")
+	assert.Contains(t, cloudInit.UserData, "Content-Type: text/x-shellscript; charset=\"utf-8\"", "shell script part")
+	assert.Contains(t, cloudInit.UserData, "echo hello", "shell script content")
+}
+
+func TestCloudInitBuilderValidation(t *testing.T) {
+	_, err := NewCloudInitBuilder().
+		Timezone("Not/AZone").
+		AddUser(CloudInitUser{}).
+		Build()
+	assert.Error(t, err, "invalid timezone and user")
+
+	_, err = NewCloudInitBuilder().
+		AddFile(CloudInitFile{Path: "/var/big", Content: strings.Repeat("x", cloudInitBuilderMaxDataBytes)}).
+		Build()
+	assert.Error(t, err, "user data too large")
+}
@@ -65,7 +65,8 @@ type Device struct {
 	TrafficEstimated float64          `json:"trafficEstimated"`
 	TrafficMB        int64            `json:"trafficMB"`
 	DCName           string           `json:"dcName"`
-	// TODO assets, dcDescription, ips, networkRoutes, ports,
+	IPs              []DeviceIP       `json:"ips"`
+	// TODO assets, dcDescription, networkRoutes, ports,
 
 	Extra map[string]interface{} `json:"extra"`
 }
@@ -118,6 +119,14 @@ type DeviceGetAllFilter struct {
 
 type DeviceClient interface {
 	GetAll(ctx context.Context, filter DeviceGetAllFilter) ([]apiv2.Device, error)
+
+	ListIPs(ctx context.Context, id apiv2.DeviceID) ([]DeviceIP, error)
+	AddIP(ctx context.Context, id apiv2.DeviceID, ipType DeviceIPType) (*DeviceIP, error)
+	RemoveIP(ctx context.Context, id apiv2.DeviceID, addr netip.Addr) error
+	SetPrimaryIP(ctx context.Context, id apiv2.DeviceID, addr netip.Addr) error
+
+	OpenConsole(ctx context.Context, id apiv2.DeviceID, allowedIP netip.Addr) (*ConsoleSession, error)
+	CloseConsole(ctx context.Context, id apiv2.DeviceID) error
 }
 
 type deviceClient struct {
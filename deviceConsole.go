@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rackcorpcloud/rackcorp-api-go/apiv2"
+	"github.com/rackcorpcloud/rackcorp-api-go/internal"
+)
+
+// ConsoleSession is an open VNC console on a device.
+// Connection details are populated from the completed OPENVNC transaction when Rackcorp provides them,
+// otherwise Info holds the raw transaction status information.
+type ConsoleSession struct {
+	DeviceID  apiv2.DeviceID
+	AllowedIP netip.Addr
+	Host      string
+	Port      int
+	Password  string
+	Expires   time.Time
+	Info      string
+
+	dc     *deviceClient
+	closed bool
+}
+
+type consoleDetails struct {
+	Host     string           `json:"host"`
+	Port     internal.JSONInt `json:"port"`
+	Password string           `json:"password"`
+	Expires  internal.JSONInt `json:"expires"` // unix epoch
+}
+
+func (dc *deviceClient) OpenConsole(ctx context.Context, id apiv2.DeviceID, allowedIP netip.Addr) (*ConsoleSession, error) {
+	if id == 0 {
+		return nil, errors.New("id parameter is required")
+	}
+	if !allowedIP.IsValid() {
+		return nil, errors.New("allowedIP parameter is required")
+	}
+
+	transaction, err := dc.c.TransactionCreateWithData(
+		ctx,
+		TransactionTypeOpenVNC,
+		TransactionObjectTypeDevice,
+		strconv.Itoa(int(id)),
+		true,
+		TransactionOpenVNCData{AllowedIP: allowedIP},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open console for device Id '%d': %w", id, err)
+	}
+
+	// The console may be open on Rackcorp's side from here on, so it is closed on any later error
+	closeOnError := func(err error) error {
+		closeErr := dc.CloseConsole(context.WithoutCancel(ctx), id)
+		return errors.Join(err, closeErr)
+	}
+
+	transaction, err = dc.c.TransactionWait(ctx, transaction.TransactionId)
+	if err != nil {
+		return nil, closeOnError(fmt.Errorf("failed to open console for device Id '%d': %w", id, err))
+	}
+
+	session := &ConsoleSession{
+		DeviceID:  id,
+		AllowedIP: allowedIP,
+		Info:      transaction.StatusInfo,
+		dc:        dc,
+	}
+
+	for _, raw := range []string{transaction.StatusInfo, transaction.Data} {
+		raw = strings.TrimSpace(raw)
+		if !strings.HasPrefix(raw, "{") {
+			continue
+		}
+		var details consoleDetails
+		if err := json.Unmarshal([]byte(raw), &details); err != nil {
+			return nil, closeOnError(fmt.Errorf("failed to JSON decode console details: %w", err))
+		}
+		session.Host = details.Host
+		session.Port = details.Port.Int()
+		session.Password = details.Password
+		if details.Expires != 0 {
+			session.Expires = time.Unix(int64(details.Expires), 0)
+		}
+		break
+	}
+
+	return session, nil
+}
+
+func (dc *deviceClient) CloseConsole(ctx context.Context, id apiv2.DeviceID) error {
+	if id == 0 {
+		return errors.New("id parameter is required")
+	}
+
+	transaction, err := dc.c.TransactionCreate(
+		ctx,
+		TransactionTypeCloseVNC,
+		TransactionObjectTypeDevice,
+		strconv.Itoa(int(id)),
+		true,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to close console for device Id '%d': %w", id, err)
+	}
+
+	_, err = dc.c.TransactionWait(ctx, transaction.TransactionId)
+	if err != nil {
+		return fmt.Errorf("failed to close console for device Id '%d': %w", id, err)
+	}
+
+	return nil
+}
+
+// Close closes the console session. It is safe to call more than once, so it can be deferred
+// straight after OpenConsole returns.
+func (s *ConsoleSession) Close(ctx context.Context) error {
+	if s == nil || s.closed {
+		return nil
+	}
+	err := s.dc.CloseConsole(ctx, s.DeviceID)
+	if err != nil {
+		return err
+	}
+	s.closed = true
+	return nil
+}
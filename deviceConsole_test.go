@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceOpenConsole(t *testing.T) {
+	defer gock.OffAll()
+
+	const deviceId = 5075
+	const openResponseBody = `{"data":{"objType":"DEVICE","type":"OPENVNC","data":"198.51.100.7","confirmationRequired":false,"confirmationText":"","rcTransactionId":141416,"objId":"5075"},"code":"OK","message":"Transaction successfully created"}`
+	const openedResponseBody = `{"rcTransaction":{"rcTransactionId":"141416","objType":"DEVICE","objId":"5075","method":"OPENVNC","data":"198.51.100.7","status":"COMPLETED","statusInfo":"{\"host\":\"vnc.example.net\",\"port\":\"5901\",\"password\":\"s3cret\",\"expires\":1760000000}"},"code":"OK","message":"Transaction lookup successful"}`
+	const closeResponseBody = `{"data":{"objType":"DEVICE","type":"CLOSEVNC","data":null,"confirmationRequired":false,"confirmationText":"","rcTransactionId":141417,"objId":"5075"},"code":"OK","message":"Transaction successfully created"}`
+	const closedResponseBody = `{"rcTransaction":{"rcTransactionId":"141417","objType":"DEVICE","objId":"5075","method":"CLOSEVNC","status":"COMPLETED"},"code":"OK","message":"Transaction lookup successful"}`
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "5075", "type": "OPENVNC", "confirmation": true, "data": "198.51.100.7"}).
+		Reply(200).
+		BodyString(openResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		Reply(200).
+		BodyString(openedResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "5075", "type": "CLOSEVNC", "confirmation": true, "data": ""}).
+		Reply(200).
+		BodyString(closeResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		Reply(200).
+		BodyString(closedResponseBody)
+
+	session, err := client.Device().OpenConsole(context.TODO(), deviceId, netip.MustParseAddr("198.51.100.7"))
+	require.NoError(t, err, "OpenConsole error")
+	assert.Equal(t, "vnc.example.net", session.Host, "Host")
+	assert.Equal(t, 5901, session.Port, "Port")
+	assert.Equal(t, "s3cret", session.Password, "Password")
+	assert.Equal(t, time.Unix(1760000000, 0), session.Expires, "Expires")
+
+	require.NoError(t, session.Close(context.TODO()), "Close error")
+	require.NoError(t, session.Close(context.TODO()), "second Close error")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+}
+
+func TestDeviceOpenConsoleClosesOnError(t *testing.T) {
+	defer gock.OffAll()
+
+	const deviceId = 5075
+	const openResponseBody = `{"data":{"objType":"DEVICE","type":"OPENVNC","data":"198.51.100.7","confirmationRequired":false,"confirmationText":"","rcTransactionId":141416,"objId":"5075"},"code":"OK","message":"Transaction successfully created"}`
+	const openedResponseBody = `{"rcTransaction":{"rcTransactionId":"141416","objType":"DEVICE","objId":"5075","method":"OPENVNC","data":"198.51.100.7","status":"COMPLETED","statusInfo":"{\"host\":"},"code":"OK","message":"Transaction lookup successful"}`
+	const closeResponseBody = `{"data":{"objType":"DEVICE","type":"CLOSEVNC","data":null,"confirmationRequired":false,"confirmationText":"","rcTransactionId":141417,"objId":"5075"},"code":"OK","message":"Transaction successfully created"}`
+	const closedResponseBody = `{"rcTransaction":{"rcTransactionId":"141417","objType":"DEVICE","objId":"5075","method":"CLOSEVNC","status":"COMPLETED"},"code":"OK","message":"Transaction lookup successful"}`
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "5075", "type": "OPENVNC", "confirmation": true, "data": "198.51.100.7"}).
+		Reply(200).
+		BodyString(openResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		Reply(200).
+		BodyString(openedResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "5075", "type": "CLOSEVNC", "confirmation": true, "data": ""}).
+		Reply(200).
+		BodyString(closeResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		Reply(200).
+		BodyString(closedResponseBody)
+
+	session, err := client.Device().OpenConsole(context.TODO(), deviceId, netip.MustParseAddr("198.51.100.7"))
+	assert.Error(t, err, "OpenConsole error")
+	assert.Nil(t, session, "session")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone: console must be closed after a failed open")
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+
+	"github.com/rackcorpcloud/rackcorp-api-go/apiv2"
+	"github.com/rackcorpcloud/rackcorp-api-go/internal"
+)
+
+type DeviceIPType string
+
+const (
+	DeviceIPTypeIPv4 DeviceIPType = "IPV4"
+	DeviceIPTypeIPv6 DeviceIPType = "IPV6"
+)
+
+// DeviceIP is an IP address assigned to a device.
+// Network carries both the network address and the netmask as a prefix length.
+// RawAddress is the address as sent by Rackcorp. When it, or the network, could not be parsed
+// the unparsed fields are left zero and ParseError is set, so that one bad IP doesn't fail DeviceGet.
+type DeviceIP struct {
+	Address    netip.Addr
+	RawAddress string
+	ParseError error
+	Primary    bool
+	RouteType  string
+	Policy     string
+	Network    netip.Prefix
+	Gateway    netip.Addr
+	NetworkID  NetworkID
+	VLANID     int
+	PortID     int
+}
+
+type deviceIP struct {
+	IPAddress      string           `json:"ipAddress"`
+	Primary        bool             `json:"primary"`
+	RouteType      string           `json:"routeType"`
+	NetworkNetwork string           `json:"networkNetwork"`
+	NetworkGateway string           `json:"networkGateway"`
+	NetworkNetmask string           `json:"networkNetmask"`
+	NetworkVLANID  internal.JSONInt `json:"networkVLANID"`
+	Policy         string           `json:"policy"`
+	PortID         internal.JSONInt `json:"portId"`
+	NetworkID      internal.JSONInt `json:"networkId"`
+	DeviceID       internal.JSONInt `json:"deviceId"`
+}
+
+func (ip deviceIP) ToDeviceIP() (DeviceIP, error) {
+	result := DeviceIP{
+		RawAddress: ip.IPAddress,
+		Primary:    ip.Primary,
+		RouteType:  ip.RouteType,
+		Policy:     ip.Policy,
+		NetworkID:  NetworkID(ip.NetworkID),
+		VLANID:     ip.NetworkVLANID.Int(),
+		PortID:     ip.PortID.Int(),
+	}
+
+	addr, err := netip.ParseAddr(ip.IPAddress)
+	if err != nil {
+		// Some IPs are sent in CIDR form, e.g. "192.0.2.10/24"
+		prefix, prefixErr := netip.ParsePrefix(ip.IPAddress)
+		if prefixErr != nil {
+			return result, fmt.Errorf("failed to parse IP address %q: %w", ip.IPAddress, err)
+		}
+		addr = prefix.Addr()
+		result.Network = prefix.Masked()
+	}
+	result.Address = addr
+
+	var errs []error
+	if len(ip.NetworkGateway) > 0 {
+		gateway, err := netip.ParseAddr(ip.NetworkGateway)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse network gateway %q: %w", ip.NetworkGateway, err))
+		}
+		result.Gateway = gateway
+	}
+
+	if len(ip.NetworkNetwork) > 0 {
+		network, err := netip.ParseAddr(ip.NetworkNetwork)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse network %q: %w", ip.NetworkNetwork, err))
+		} else if len(ip.NetworkNetmask) == 0 {
+			result.Network = netip.PrefixFrom(network, network.BitLen())
+		} else if bits, err := netmaskBits(ip.NetworkNetmask, network.BitLen()); err != nil {
+			errs = append(errs, err)
+		} else {
+			result.Network = netip.PrefixFrom(network, bits)
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (ip *DeviceIP) UnmarshalJSON(data []byte) error {
+	var tmp deviceIP
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	result, err := tmp.ToDeviceIP()
+	result.ParseError = err
+	*ip = result
+	return nil
+}
+
+// netmaskBits accepts either a dotted netmask ("255.255.255.0") or a prefix length ("64")
+// for an address family of bitLen bits.
+func netmaskBits(netmask string, bitLen int) (int, error) {
+	if bits, err := strconv.Atoi(netmask); err == nil {
+		if bits < 0 || bits > bitLen {
+			return 0, fmt.Errorf("netmask /%d is out of range for a %d bit address", bits, bitLen)
+		}
+		return bits, nil
+	}
+	mask, err := netip.ParseAddr(netmask)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse netmask %q: %w", netmask, err)
+	}
+	if mask.BitLen() != bitLen {
+		return 0, fmt.Errorf("netmask %q is not for a %d bit address", netmask, bitLen)
+	}
+	bits := 0
+	for i, b := range mask.AsSlice() {
+		if b != 0 && bits != i*8 {
+			return 0, fmt.Errorf("netmask %q is not contiguous", netmask)
+		}
+		for ; b&0x80 != 0; b <<= 1 {
+			bits++
+		}
+		if b != 0 {
+			return 0, fmt.Errorf("netmask %q is not contiguous", netmask)
+		}
+	}
+	return bits, nil
+}
+
+type deviceIPAddRequest struct {
+	Type DeviceIPType `json:"type"`
+}
+
+type deviceIPUpdateRequest struct {
+	Primary bool `json:"primary"`
+}
+
+type deviceIPChange struct {
+	deviceIP
+	TransactionId internal.JSONInt `json:"rcTransactionId,omitempty"`
+}
+
+type deviceIPResponse struct {
+	response
+	Data *deviceIPChange `json:"data"`
+}
+
+func (dc *deviceClient) ListIPs(ctx context.Context, id apiv2.DeviceID) ([]DeviceIP, error) {
+	device, err := dc.c.DeviceGet(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+	return device.IPs, nil
+}
+
+func (dc *deviceClient) AddIP(ctx context.Context, id apiv2.DeviceID, ipType DeviceIPType) (*DeviceIP, error) {
+	if id == 0 {
+		return nil, errors.New("id parameter is required")
+	}
+	if ipType != DeviceIPTypeIPv4 && ipType != DeviceIPTypeIPv6 {
+		return nil, fmt.Errorf("unsupported IP type %q", ipType)
+	}
+
+	req := &deviceIPAddRequest{
+		Type: ipType,
+	}
+
+	var resp deviceIPResponse
+	err := dc.c.httpRestJson(ctx, http.MethodPost, fmt.Sprintf("devices/%d/ips", id), req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add IP to device Id '%d': %w", id, err)
+	}
+
+	if !resp.IsOK() || resp.Data == nil {
+		return nil, newApiError(resp.response, nil)
+	}
+
+	ip, err := resp.Data.ToDeviceIP()
+	ip.ParseError = err
+
+	err = dc.waitForIPChange(ctx, resp.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ip, nil
+}
+
+func (dc *deviceClient) RemoveIP(ctx context.Context, id apiv2.DeviceID, addr netip.Addr) error {
+	if id == 0 {
+		return errors.New("id parameter is required")
+	}
+	if !addr.IsValid() {
+		return errors.New("addr parameter is required")
+	}
+
+	var resp deviceIPResponse
+	err := dc.c.httpRestJson(ctx, http.MethodDelete, fmt.Sprintf("devices/%d/ips/%s", id, addr), emptyRequest{}, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to remove IP %s from device Id '%d': %w", addr, id, err)
+	}
+
+	if !resp.IsOK() {
+		return newApiError(resp.response, nil)
+	}
+
+	return dc.waitForIPChange(ctx, resp.Data)
+}
+
+func (dc *deviceClient) SetPrimaryIP(ctx context.Context, id apiv2.DeviceID, addr netip.Addr) error {
+	if id == 0 {
+		return errors.New("id parameter is required")
+	}
+	if !addr.IsValid() {
+		return errors.New("addr parameter is required")
+	}
+
+	req := &deviceIPUpdateRequest{
+		Primary: true,
+	}
+
+	var resp deviceIPResponse
+	err := dc.c.httpRestJson(ctx, http.MethodPut, fmt.Sprintf("devices/%d/ips/%s", id, addr), req, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to set primary IP %s for device Id '%d': %w", addr, id, err)
+	}
+
+	if !resp.IsOK() {
+		return newApiError(resp.response, nil)
+	}
+
+	return dc.waitForIPChange(ctx, resp.Data)
+}
+
+func (dc *deviceClient) waitForIPChange(ctx context.Context, change *deviceIPChange) error {
+	if change == nil || change.TransactionId == 0 {
+		return nil
+	}
+	_, err := dc.c.TransactionWait(ctx, strconv.Itoa(change.TransactionId.Int()))
+	return err
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceListIPs(t *testing.T) {
+	defer gock.OffAll()
+
+	const deviceId = 5075
+	responseBody := getTestDataString(t, "device.get.responseBody.json")
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Get(fmt.Sprintf("/api/v2.9/devices/%d", deviceId)).
+		Reply(200).
+		BodyString(responseBody)
+
+	ips, err := client.Device().ListIPs(context.TODO(), deviceId)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+
+	require.NoError(t, err, "ListIPs error")
+	require.Len(t, ips, 1, "ips")
+	assert.Equal(t, netip.MustParseAddr("192.0.2.123"), ips[0].Address, "Address")
+	assert.True(t, ips[0].Primary, "Primary")
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), ips[0].Network, "Network")
+	assert.Equal(t, netip.MustParseAddr("192.0.2.1"), ips[0].Gateway, "Gateway")
+	assert.Equal(t, NetworkID(25), ips[0].NetworkID, "NetworkID")
+}
+
+func TestDeviceAddIP(t *testing.T) {
+	defer gock.OffAll()
+
+	const deviceId = 5075
+	const responseBody = `{"data":{"ipAddress":"2001:db8::10","primary":false,"routeType":"UNICAST","networkNetwork":"2001:db8::","networkGateway":"2001:db8::1","networkNetmask":"64","networkId":26,"deviceId":5075,"rcTransactionId":141415},"code":"OK","message":"IP added"}`
+	const transactionResponseBody = `{"rcTransaction":{"rcTransactionId":"141415","objType":"DEVICE","objId":"5075","method":"REFRESHCONFIG","status":"COMPLETED"},"code":"OK","message":"Transaction lookup successful"}`
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post(fmt.Sprintf("/api/v2.9/devices/%d/ips", deviceId)).
+		JSON(map[string]string{"type": "IPV6"}).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		Reply(200).
+		BodyString(transactionResponseBody)
+
+	ip, err := client.Device().AddIP(context.TODO(), deviceId, DeviceIPTypeIPv6)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+
+	require.NoError(t, err, "AddIP error")
+	assert.Equal(t, netip.MustParseAddr("2001:db8::10"), ip.Address, "Address")
+	assert.Equal(t, netip.MustParsePrefix("2001:db8::/64"), ip.Network, "Network")
+	assert.False(t, ip.Primary, "Primary")
+}
+
+func TestDeviceSetPrimaryIP(t *testing.T) {
+	defer gock.OffAll()
+
+	const deviceId = 5075
+	const responseBody = `{"code":"OK","message":"IP updated"}`
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Put(fmt.Sprintf("/api/v2.9/devices/%d/ips/192.0.2.124", deviceId)).
+		JSON(map[string]bool{"primary": true}).
+		Reply(200).
+		BodyString(responseBody)
+
+	err := client.Device().SetPrimaryIP(context.TODO(), deviceId, netip.MustParseAddr("192.0.2.124"))
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+
+	require.NoError(t, err, "SetPrimaryIP error")
+}
+
+func TestNetmaskBits(t *testing.T) {
+	for netmask, expected := range map[string]int{
+		"255.255.255.0":   24,
+		"255.255.255.224": 27,
+		"0.0.0.0":         0,
+		"32":              32,
+	} {
+		bits, err := netmaskBits(netmask, 32)
+		require.NoError(t, err, "netmaskBits(%q)", netmask)
+		assert.Equal(t, expected, bits, "netmaskBits(%q)", netmask)
+	}
+
+	bits, err := netmaskBits("64", 128)
+	require.NoError(t, err, "netmaskBits(\"64\", 128)")
+	assert.Equal(t, 64, bits, "netmaskBits(\"64\", 128)")
+
+	_, err = netmaskBits("255.0.255.0", 32)
+	assert.Error(t, err, "non-contiguous netmask")
+	_, err = netmaskBits("40", 32)
+	assert.Error(t, err, "prefix length out of range for IPv4")
+	_, err = netmaskBits("255.255.255.0", 128)
+	assert.Error(t, err, "IPv4 netmask for IPv6 network")
+}
+
+func TestDeviceIPUnmarshalTolerant(t *testing.T) {
+	var ips []DeviceIP
+	err := json.Unmarshal([]byte(`[
+		{"ipAddress":"192.0.2.10/24","primary":true},
+		{"ipAddress":"not-an-ip","networkNetwork":"192.0.2.0","networkNetmask":"24"},
+		{"ipAddress":"192.0.2.11","networkNetwork":"192.0.2.0","networkNetmask":"40","networkGateway":"192.0.2.1"}
+	]`), &ips)
+	require.NoError(t, err, "Unmarshal error")
+	require.Len(t, ips, 3, "ips")
+
+	assert.NoError(t, ips[0].ParseError, "CIDR form ParseError")
+	assert.Equal(t, netip.MustParseAddr("192.0.2.10"), ips[0].Address, "CIDR form Address")
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), ips[0].Network, "CIDR form Network")
+
+	assert.Error(t, ips[1].ParseError, "malformed address ParseError")
+	assert.False(t, ips[1].Address.IsValid(), "malformed address Address")
+	assert.Equal(t, "not-an-ip", ips[1].RawAddress, "malformed address RawAddress")
+
+	assert.Error(t, ips[2].ParseError, "out of range netmask ParseError")
+	assert.Equal(t, netip.MustParseAddr("192.0.2.11"), ips[2].Address, "out of range netmask Address")
+	assert.Equal(t, netip.MustParseAddr("192.0.2.1"), ips[2].Gateway, "out of range netmask Gateway")
+	assert.False(t, ips[2].Network.IsValid(), "out of range netmask Network")
+}
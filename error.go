@@ -1,5 +1,14 @@
 package api
 
+import (
+	"errors"
+	"strings"
+)
+
+// ErrConflict is wrapped by the error returned when an update is rejected because the resource
+// was modified after it was read.
+var ErrConflict = errors.New("resource was modified concurrently")
+
 type ApiError struct {
 	Message string
 	Err     error
@@ -20,6 +29,10 @@ func (e *ApiError) Error() string {
 	return e.Message + ": " + e.Err.Error()
 }
 
+func (e *ApiError) Unwrap() error {
+	return e.Err
+}
+
 func newApiError(resp response, err error) *ApiError {
 	result := &ApiError{
 		Err: err,
@@ -35,3 +48,9 @@ func newApiError(resp response, err error) *ApiError {
 	result.Message = resp.Code
 	return result
 }
+
+// isVersionConflict reports whether an update was rejected because the version sent is no longer current.
+// Only the CONFLICT code is a conflict, other errors mentioning a version, e.g. of the API, are not.
+func (r *response) isVersionConflict() bool {
+	return strings.EqualFold(r.Code, "CONFLICT")
+}
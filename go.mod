@@ -6,11 +6,11 @@ require (
 	github.com/h2non/gock v1.2.0
 	github.com/stretchr/testify v1.5.1
 	gopkg.in/ini.v1 v1.67.0
+	gopkg.in/yaml.v2 v2.2.8
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/h2non/parth v0.0.0-20190131123155-b4df798d6542 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
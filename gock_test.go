@@ -1,6 +1,10 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
 	"net/http/httputil"
 	"testing"
 
@@ -24,3 +28,42 @@ func assertGockNoUnmatchedRequests(t *testing.T) {
 		}
 	}
 }
+
+// gockMatchLegacyCommand matches legacy JSON API requests for the command cmd, decoding the
+// request body into body when it is not nil.
+func gockMatchLegacyCommand(cmd string, body any) gock.MatchFunc {
+	return func(req *http.Request, _ *gock.Request) (bool, error) {
+		if req.Body == nil {
+			return false, nil
+		}
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return false, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+
+		var legacy legacyRequest
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return false, err
+		}
+		if legacy.Command != cmd {
+			return false, nil
+		}
+		if body != nil {
+			if err := json.Unmarshal(data, body); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// gockRegionGetAll mocks the region catalogue, which is read once per client to validate load balancer regions.
+func gockRegionGetAll(t *testing.T) {
+	t.Helper()
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("region.getall", nil)).
+		Reply(200).
+		BodyString(getTestDataString(t, "region.getall.responseBody.json"))
+}
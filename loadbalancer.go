@@ -2,9 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/rackcorpcloud/rackcorp-api-go/apiv2"
 	"github.com/rackcorpcloud/rackcorp-api-go/internal"
 )
 
@@ -26,6 +32,11 @@ const (
 	LoadBalancerScopeLocal  LoadBalancerScope = "local"
 )
 
+// LoadBalancerMaxScopeInstances bounds the ScopeInstances of a local scope load balancer checked by Validate.
+// It is a client side guard against typos rather than a documented Rackcorp limit, so it can be raised, or
+// set to zero to only check that ScopeInstances is not negative. Zero instances uses the Rackcorp default.
+var LoadBalancerMaxScopeInstances = 10
+
 type LoadBalancerType string
 
 const (
@@ -36,6 +47,14 @@ const (
 	LoadBalancerTypeUDP  LoadBalancerType = "UDP"
 )
 
+type LoadBalancerStatus string
+
+const (
+	LoadBalancerStatusActive   LoadBalancerStatus = "ACTIVE"
+	LoadBalancerStatusInactive LoadBalancerStatus = "INACTIVE"
+	LoadBalancerStatusDeleted  LoadBalancerStatus = "DELETED"
+)
+
 type LoadBalancerBalanceMode string
 
 const (
@@ -51,6 +70,93 @@ const (
 	LoadBalancerCheckModeTCP  LoadBalancerCheckMode = "TCP"
 )
 
+// LoadBalancerHealthCheck configures how backends are checked. Host, Method, Path and ExpectedStatus
+// only apply to HTTP checks.
+type LoadBalancerHealthCheck struct {
+	Mode           LoadBalancerCheckMode
+	Host           string
+	Method         string
+	Path           string
+	ExpectedStatus int
+	BackendTLS     bool
+}
+
+func (hc LoadBalancerHealthCheck) Validate(lbType LoadBalancerType) error {
+	var errs []error
+	httpOnly := hc.Host != "" || hc.Method != "" || hc.Path != "" || hc.ExpectedStatus != 0
+	if httpOnly && (lbType == LoadBalancerTypeTCP || lbType == LoadBalancerTypeUDP) {
+		errs = append(errs, fmt.Errorf("HTTP health check options are not supported for %s load balancers", lbType))
+	} else if httpOnly && hc.Mode != LoadBalancerCheckModeHTTP {
+		errs = append(errs, fmt.Errorf("HTTP health check options are not supported for check mode %q", hc.Mode))
+	}
+	switch hc.Mode {
+	case "", LoadBalancerCheckModeHTTP, LoadBalancerCheckModeTCP:
+	default:
+		errs = append(errs, fmt.Errorf("health check mode %q is not valid", hc.Mode))
+	}
+	switch hc.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPost:
+	default:
+		errs = append(errs, fmt.Errorf("health check method %q is not valid", hc.Method))
+	}
+	if hc.Path != "" && !strings.HasPrefix(hc.Path, "/") {
+		errs = append(errs, fmt.Errorf("health check path %q must start with /", hc.Path))
+	}
+	if hc.ExpectedStatus != 0 && (hc.ExpectedStatus < 100 || hc.ExpectedStatus > 599) {
+		errs = append(errs, fmt.Errorf("health check expected status %d is not valid", hc.ExpectedStatus))
+	}
+	return errors.Join(errs...)
+}
+
+// LoadBalancerDiscovery configures DNS based discovery of backends. The records of DNSName are used
+// as the backends, so static Backends must not also be set. MaxBackends of zero uses the Rackcorp default.
+type LoadBalancerDiscovery struct {
+	DNSName     string
+	MaxBackends int
+}
+
+func (d LoadBalancerDiscovery) IsZero() bool {
+	return d == LoadBalancerDiscovery{}
+}
+
+func (d LoadBalancerDiscovery) Validate() error {
+	if d.DNSName == "" {
+		if d.MaxBackends != 0 {
+			return errors.New("discovery MaxBackends requires DNSName")
+		}
+		return nil
+	}
+	if !isValidHostname(d.DNSName) {
+		return fmt.Errorf("discovery DNSName %q is not a valid DNS name", d.DNSName)
+	}
+	if d.MaxBackends < 0 {
+		return fmt.Errorf("discovery MaxBackends %d must not be negative", d.MaxBackends)
+	}
+	return nil
+}
+
+// isValidHostname checks the RFC 1123 syntax of a host name, allowing a trailing dot.
+func isValidHostname(hostname string) bool {
+	hostname = strings.TrimSuffix(hostname, ".")
+	if len(hostname) == 0 || len(hostname) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 type LoadBalancerTCPProxyMode int
 
 const (
@@ -64,11 +170,27 @@ type LoadBalancerBackend struct {
 	Port     int
 	TLS      bool
 	Timeout  time.Duration
-	Weight   int
+	// Weight of zero is not sent, leaving the Rackcorp default. Set Drained to send a weight of zero,
+	// so that the backend receives no new traffic. Get sets Drained for backends with a weight of zero.
+	Weight  int
+	Drained bool
+	// UUID is assigned by Rackcorp and identifies the backend across updates, even if it is renamed.
 	UUID     string
 	TTL      time.Duration
 	TCPProxy LoadBalancerTCPProxyMode
 	PortMask []int
+
+	// Created and Modified are read only and are not sent on create or update.
+	Created  time.Time
+	Modified time.Time
+}
+
+// InRotationFor returns how long the backend has existed as of now, or zero if Created is unknown.
+func (b LoadBalancerBackend) InRotationFor(now time.Time) time.Duration {
+	if b.Created.IsZero() {
+		return 0
+	}
+	return now.Sub(b.Created)
 }
 
 type LoadBalancer struct {
@@ -81,25 +203,120 @@ type LoadBalancer struct {
 	HostSourceForceHost  string
 	BackendHostnameForce string
 	Type                 LoadBalancerType
-	// Status               string // TODO LoadBalancerStatus
-	DateCreated         time.Time
-	DateModified        time.Time
-	Version             int
-	Scope               LoadBalancerScope
-	ScopeNetworkID      NetworkID
-	ScopeInstances      int
-	MonthlyAllocationMB int
-	MonthlyUsageMB      int
-	TrafficRemainingMB  int
-
+	Status               LoadBalancerStatus
+	DateCreated          time.Time
+	DateModified         time.Time
+	Version              int
+	Scope                LoadBalancerScope
+	ScopeNetworkID       NetworkID
+	ScopeInstances       int
+	MonthlyAllocationMB  int
+	MonthlyUsageMB       int
+	TrafficRemainingMB   int
+
+	ACLs             []LoadBalancerACL
 	Aliases          []string
 	AutoUpgradeHTTPS bool
 	Backends         []LoadBalancerBackend
 	BalanceMode      LoadBalancerBalanceMode
-	CheckMode        LoadBalancerCheckMode
-	Ports            []int
-	RefreshPatterns  []LoadBalancerRefreshPattern
-	Regions          []RegionID
+	// Deprecated: use HealthCheck.Mode. CheckMode is set by Get and, if HealthCheck.Mode is empty,
+	// sent as the check mode on create and update.
+	CheckMode       LoadBalancerCheckMode
+	Discovery       LoadBalancerDiscovery
+	HealthCheck     LoadBalancerHealthCheck
+	Ports           []int
+	RefreshPatterns []LoadBalancerRefreshPattern
+	Regions         []RegionID
+	TLS             bool
+	AllowDirectSSL  bool
+
+	// Certificates is read only, use UploadCertificate, ReplaceCertificate and RemoveCertificate to change it.
+	Certificates []LoadBalancerCertificate
+
+	// loaded is the load balancer as returned by Rackcorp, so that update only validates what changed.
+	// It is nil for a new load balancer.
+	loaded *loadedLoadBalancer
+}
+
+// loadedLoadBalancer holds the fields of a load balancer as returned by Rackcorp that update validates, and
+// the deprecated CheckMode to tell whether it was changed.
+type loadedLoadBalancer struct {
+	regions        []RegionID
+	customerID     CustomerID
+	scope          LoadBalancerScope
+	scopeNetworkID NetworkID
+	checkMode      LoadBalancerCheckMode
+}
+
+// addedRegions returns the Regions that were not returned by Rackcorp, i.e. all of them for a new load balancer.
+func (lb LoadBalancer) addedRegions() []RegionID {
+	if lb.loaded == nil {
+		return lb.Regions
+	}
+	var added []RegionID
+	for _, id := range lb.Regions {
+		if !slices.Contains(lb.loaded.regions, id) {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// scopeNetworkChanged reports whether the scope, scope network or customer differ from those returned by
+// Rackcorp, i.e. whether the scope network must be validated again.
+func (lb LoadBalancer) scopeNetworkChanged() bool {
+	return lb.loaded == nil ||
+		lb.Scope != lb.loaded.scope ||
+		lb.ScopeNetworkID != lb.loaded.scopeNetworkID ||
+		lb.CustomerID != lb.loaded.customerID
+}
+
+// healthCheck returns HealthCheck with Mode taken from the deprecated CheckMode if it is empty, or if CheckMode
+// was changed since the load balancer was returned by Rackcorp.
+func (lb LoadBalancer) healthCheck() LoadBalancerHealthCheck {
+	hc := lb.HealthCheck
+	if hc.Mode == "" || lb.loaded != nil && lb.CheckMode != lb.loaded.checkMode {
+		hc.Mode = lb.CheckMode
+	}
+	return hc
+}
+
+// Validate checks the load balancer for errors that the Rackcorp API would otherwise reject on create or update.
+func (lb LoadBalancer) Validate() error {
+	var errs []error
+	if err := lb.Discovery.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("discovery: %w", err))
+	}
+	if !lb.Discovery.IsZero() && len(lb.Backends) > 0 {
+		errs = append(errs, errors.New("backends must be empty when discovery is set"))
+	}
+	if err := lb.healthCheck().Validate(lb.Type); err != nil {
+		errs = append(errs, fmt.Errorf("health check: %w", err))
+	}
+	switch lb.Scope {
+	case LoadBalancerScopeLocal:
+		if lb.ScopeNetworkID == 0 {
+			errs = append(errs, errors.New("local scope requires a scope network ID"))
+		}
+		if lb.ScopeInstances < 0 || LoadBalancerMaxScopeInstances > 0 && lb.ScopeInstances > LoadBalancerMaxScopeInstances {
+			errs = append(errs, fmt.Errorf("scope instances %d must be between 0 and %d", lb.ScopeInstances, LoadBalancerMaxScopeInstances))
+		}
+	case "", LoadBalancerScopeGlobal:
+		// ScopeNetworkID and ScopeInstances are ignored, and may be set to defaults by Rackcorp.
+	default:
+		errs = append(errs, fmt.Errorf("scope %q is not valid", lb.Scope))
+	}
+	for idx, acl := range lb.ACLs {
+		if err := acl.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("acl %d: %w", idx, err))
+		}
+	}
+	for idx, pattern := range lb.RefreshPatterns {
+		if err := pattern.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("refresh pattern %d: %w", idx, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 type LoadBalancerRefreshPattern struct {
@@ -124,7 +341,7 @@ type LoadBalancerRefreshPattern struct {
 	RedirectURL                string
 	RedirectPreserveParams     bool
 	RedirectForceHTTPS         bool
-	IPRestrictionDefaultPolicy string
+	IPRestrictionDefaultPolicy LoadBalancerACLAction
 	IPRestrictions             []LoadBalancerIPRestriction
 }
 
@@ -141,15 +358,127 @@ const (
 	LoadBalancerACLActionDeny  LoadBalancerACLAction = "DENY"
 )
 
+func (a LoadBalancerACLAction) IsValid() bool {
+	return a == LoadBalancerACLActionAllow || a == LoadBalancerACLActionDeny
+}
+
+// LoadBalancerACL allows or denies clients in Data. RawData is set, and Data is zero, when the
+// data sent by Rackcorp could not be parsed; such an entry is sent back unchanged on update.
 type LoadBalancerACL struct {
+	ID      LoadBalancerACLID
+	Data    netip.Prefix
+	RawData string
+	Action  LoadBalancerACLAction
+}
+
+type loadBalancerACL struct {
 	ID     LoadBalancerACLID     `json:"id,omitempty"`
-	Data   string                `json:"acl_data,omitempty"` // TODO ip mask
+	Data   string                `json:"acl_data,omitempty"`
 	Action LoadBalancerACLAction `json:"acl_action,omitempty"`
 }
 
+func (a loadBalancerACL) ToLoadBalancerACL() LoadBalancerACL {
+	acl := LoadBalancerACL{
+		ID:     a.ID,
+		Action: a.Action,
+	}
+	prefix, err := parsePrefixOrAddr(a.Data)
+	if err != nil {
+		acl.RawData = a.Data
+	} else {
+		acl.Data = prefix
+	}
+	return acl
+}
+
+func convertLoadBalancerACL(acl LoadBalancerACL) loadBalancerACL {
+	return loadBalancerACL{
+		ID:     acl.ID,
+		Data:   acl.dataString(),
+		Action: acl.Action,
+	}
+}
+
+// dataString returns Data as sent to Rackcorp, or RawData if Data could not be parsed.
+func (acl LoadBalancerACL) dataString() string {
+	if !acl.Data.IsValid() && acl.RawData != "" {
+		return acl.RawData
+	}
+	return acl.Data.String()
+}
+
+func (acl LoadBalancerACL) Validate() error {
+	if !acl.Data.IsValid() && acl.RawData == "" {
+		return errors.New("load balancer ACL data must be a valid IP prefix")
+	}
+	if !acl.Action.IsValid() {
+		return fmt.Errorf("load balancer ACL action %q is not valid", acl.Action)
+	}
+	return nil
+}
+
+// parsePrefixOrAddr parses "192.0.2.0/24" or a single address "192.0.2.1", which is treated as a /32 or /128.
+func parsePrefixOrAddr(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// LoadBalancerIPRestriction allows or denies clients in IP to the URLs matched by a refresh pattern. RawIP
+// is set, and IP is zero, when the IP sent by Rackcorp could not be parsed; such a restriction is sent back
+// unchanged on update and never matches a client.
 type LoadBalancerIPRestriction struct {
-	IP     string `json:"ip,omitempty"`
-	Action string `json:"action,omitempty"`
+	IP     netip.Prefix
+	RawIP  string
+	Action LoadBalancerACLAction
+}
+
+type loadBalancerIPRestriction struct {
+	IP     string                `json:"ip,omitempty"`
+	Action LoadBalancerACLAction `json:"action,omitempty"`
+}
+
+func (r loadBalancerIPRestriction) ToLoadBalancerIPRestriction() LoadBalancerIPRestriction {
+	restriction := LoadBalancerIPRestriction{
+		Action: r.Action,
+	}
+	prefix, err := parsePrefixOrAddr(r.IP)
+	if err != nil {
+		restriction.RawIP = r.IP
+	} else {
+		restriction.IP = prefix
+	}
+	return restriction
+}
+
+func convertLoadBalancerIPRestriction(restriction LoadBalancerIPRestriction) loadBalancerIPRestriction {
+	return loadBalancerIPRestriction{
+		IP:     restriction.ipString(),
+		Action: restriction.Action,
+	}
+}
+
+// ipString returns IP as sent to Rackcorp, or RawIP if IP could not be parsed.
+func (r LoadBalancerIPRestriction) ipString() string {
+	if !r.IP.IsValid() && r.RawIP != "" {
+		return r.RawIP
+	}
+	return r.IP.String()
+}
+
+func (r LoadBalancerIPRestriction) Validate() error {
+	if !r.IP.IsValid() && r.RawIP == "" {
+		return errors.New("load balancer IP restriction IP must be a valid IP prefix")
+	}
+	if !r.Action.IsValid() {
+		return fmt.Errorf("load balancer IP restriction action %q is not valid", r.Action)
+	}
+	return nil
 }
 
 type LoadBalancerRefreshPatternID int
@@ -188,12 +517,12 @@ type loadBalancerRefreshPattern struct {
 	RedirectURL                string                       `json:"redirecturl,omitempty"`
 	RedirectPreserveParams     bool                         `json:"redirectpreserveparams,omitempty"`
 	RedirectForceHTTPS         bool                         `json:"redirectforcehttps,omitempty"`
-	IPRestrictionDefaultPolicy string                       `json:"iprestrictiondefaultpolicy,omitempty"`
-	IPRestrictions             []LoadBalancerIPRestriction  `json:"iprestrictions,omitempty"`
+	IPRestrictionDefaultPolicy LoadBalancerACLAction        `json:"iprestrictiondefaultpolicy,omitempty"`
+	IPRestrictions             []loadBalancerIPRestriction  `json:"iprestrictions,omitempty"`
 }
 
 func (p loadBalancerRefreshPattern) ToLoadBalancerRefreshPattern() LoadBalancerRefreshPattern {
-	return LoadBalancerRefreshPattern{
+	pattern := LoadBalancerRefreshPattern{
 		ID:                         p.ID,
 		RegularExpression:          p.RegularExpression,
 		MinTTL:                     time.Duration(p.MinTTL) * time.Second,
@@ -216,8 +545,43 @@ func (p loadBalancerRefreshPattern) ToLoadBalancerRefreshPattern() LoadBalancerR
 		RedirectPreserveParams:     p.RedirectPreserveParams,
 		RedirectForceHTTPS:         p.RedirectForceHTTPS,
 		IPRestrictionDefaultPolicy: p.IPRestrictionDefaultPolicy,
-		IPRestrictions:             p.IPRestrictions,
+		IPRestrictions:             make([]LoadBalancerIPRestriction, len(p.IPRestrictions)),
+	}
+	for idx, restriction := range p.IPRestrictions {
+		pattern.IPRestrictions[idx] = restriction.ToLoadBalancerIPRestriction()
 	}
+	return pattern
+}
+
+func convertLoadBalancerRefreshPattern(pattern LoadBalancerRefreshPattern) loadBalancerRefreshPattern {
+	p := loadBalancerRefreshPattern{
+		ID:                         pattern.ID,
+		RegularExpression:          pattern.RegularExpression,
+		MinTTL:                     internal.JSONInt(pattern.MinTTL.Seconds()),
+		CacheTime:                  internal.JSONInt(pattern.CacheTime.Seconds()),
+		MaxTTL:                     internal.JSONInt(pattern.MaxTTL.Seconds()),
+		CheckTTL:                   internal.JSONInt(pattern.CheckTTL.Seconds()),
+		OverrideExpire:             pattern.OverrideExpire,
+		OverrideLastModified:       pattern.OverrideLastModified,
+		IgnoreSetCookie:            pattern.IgnoreSetCookie,
+		IgnoreCacheControl:         pattern.IgnoreCacheControl,
+		CacheAuthorizedPages:       pattern.CacheAuthorizedPages,
+		BrowserRefresh:             pattern.BrowserRefresh,
+		ForceExpireMins:            internal.JSONInt(pattern.ForceExpireMins.Minutes()),
+		PseudoStreamFLV:            pattern.PseudoStreamFLV,
+		PseudoStreamH264:           pattern.PseudoStreamH264,
+		CGIIgnoreParams:            pattern.CGIIgnoreParams,
+		NoCompression:              pattern.NoCompression,
+		RedirectCode:               internal.JSONInt(pattern.RedirectCode),
+		RedirectURL:                pattern.RedirectURL,
+		RedirectPreserveParams:     pattern.RedirectPreserveParams,
+		RedirectForceHTTPS:         pattern.RedirectForceHTTPS,
+		IPRestrictionDefaultPolicy: pattern.IPRestrictionDefaultPolicy,
+	}
+	for _, restriction := range pattern.IPRestrictions {
+		p.IPRestrictions = append(p.IPRestrictions, convertLoadBalancerIPRestriction(restriction))
+	}
+	return p
 }
 
 type loadBalancerBackend struct {
@@ -226,7 +590,7 @@ type loadBalancerBackend struct {
 	Port     internal.JSONInt   `json:"port,omitempty"`
 	TLS      bool               `json:"tls,omitempty"`
 	Timeout  internal.JSONInt   `json:"timeout,omitempty"` // seconds
-	Weight   internal.JSONInt   `json:"weight,omitempty"`
+	Weight   *internal.JSONInt  `json:"weight,omitempty"`
 	UUID     string             `json:"uuid,omitempty"`
 	TTL      internal.JSONInt   `json:"ttl,omitempty"` // seconds
 	TCPProxy internal.JSONInt   `json:"tcpproxy,omitempty"`
@@ -236,72 +600,95 @@ type loadBalancerBackend struct {
 }
 
 func (b loadBalancerBackend) ToLoadBalancerBackend() LoadBalancerBackend {
-	return LoadBalancerBackend{
+	backend := LoadBalancerBackend{
 		Name:     b.Name,
 		Hostname: b.Hostname,
 		Port:     b.Port.Int(),
 		TLS:      b.TLS,
 		Timeout:  time.Duration(b.Timeout.Int()) * time.Second,
-		Weight:   b.Weight.Int(),
 		UUID:     b.UUID,
 		TTL:      time.Duration(b.TTL.Int()) * time.Second,
 		TCPProxy: LoadBalancerTCPProxyMode(b.TCPProxy),
 		PortMask: internal.JSONIntSliceInt(b.PortMask),
-		// TODO Created, Modified
 	}
+	if b.Weight != nil {
+		backend.Weight = b.Weight.Int()
+		backend.Drained = backend.Weight == 0
+	}
+	if b.Created != 0 {
+		backend.Created = time.Unix(b.Created, 0)
+	}
+	if b.Modified != 0 {
+		backend.Modified = time.Unix(b.Modified, 0)
+	}
+	return backend
 }
 
 func convertLoadBalancerBackend(lbBackend LoadBalancerBackend) loadBalancerBackend {
+	var weight *internal.JSONInt
+	if lbBackend.Drained {
+		weight = new(internal.JSONInt)
+	} else if lbBackend.Weight != 0 {
+		weight = new(internal.JSONInt)
+		*weight = internal.JSONInt(lbBackend.Weight)
+	}
 	return loadBalancerBackend{
 		Name:     lbBackend.Name,
 		Hostname: lbBackend.Hostname,
 		Port:     internal.JSONInt(lbBackend.Port),
 		TLS:      lbBackend.TLS,
 		Timeout:  internal.JSONInt(int(lbBackend.Timeout.Seconds())),
-		Weight:   internal.JSONInt(lbBackend.Weight),
+		Weight:   weight,
 		UUID:     lbBackend.UUID,
 		TTL:      internal.JSONInt(int(lbBackend.TTL.Seconds())),
 		TCPProxy: internal.JSONInt(int(lbBackend.TCPProxy)),
 		PortMask: internal.IntSliceJSONInt(lbBackend.PortMask),
-		// TODO Created, Modified
 	}
 }
 
 type existingLoadBalancer struct {
-	ID                   LoadBalancerID   `json:"id"`
-	Name                 string           `json:"name"`
-	CustomerID           CustomerID       `json:"customerid"`
-	Hostname             string           `json:"hostname"`
-	StdName              string           `json:"stdname"`
-	HostSource           string           `json:"hostsource"`
-	Type                 LoadBalancerType `json:"type"`
-	HostSourceForceHost  string           `json:"hostsourceforcehost"`
-	BackendHostnameForce string           `json:"backend_hostname_force"`
-	// Status               string            `json:"status"`
-	DateCreated         int64             `json:"datecreated"`
-	DateModified        int64             `json:"datemodified"`
-	AutoUpgradeHTTPS    bool              `json:"autoupgradehttps"`
-	Version             internal.JSONInt  `json:"version"`
-	Scope               LoadBalancerScope `json:"scope"`
-	ScopeNetworkID      NetworkID         `json:"scope_networkid"`
-	ScopeInstances      int               `json:"scope_instances"`
-	MonthlyAllocationMB internal.JSONInt  `json:"monthlyallocationmb"`
-	MonthlyUsageMB      int               `json:"monthlyusagemb"`
-	TrafficRemainingMB  int               `json:"trafficremainingmb"`
+	ID                   LoadBalancerID     `json:"id"`
+	Name                 string             `json:"name"`
+	CustomerID           CustomerID         `json:"customerid"`
+	Hostname             string             `json:"hostname"`
+	StdName              string             `json:"stdname"`
+	HostSource           string             `json:"hostsource"`
+	Type                 LoadBalancerType   `json:"type"`
+	HostSourceForceHost  string             `json:"hostsourceforcehost"`
+	BackendHostnameForce string             `json:"backend_hostname_force"`
+	Status               LoadBalancerStatus `json:"status"`
+	DateCreated          int64              `json:"datecreated"`
+	DateModified         int64              `json:"datemodified"`
+	AutoUpgradeHTTPS     bool               `json:"autoupgradehttps"`
+	Version              internal.JSONInt   `json:"version"`
+	Scope                LoadBalancerScope  `json:"scope"`
+	ScopeNetworkID       NetworkID          `json:"scope_networkid"`
+	ScopeInstances       int                `json:"scope_instances"`
+	MonthlyAllocationMB  internal.JSONInt   `json:"monthlyallocationmb"`
+	MonthlyUsageMB       int                `json:"monthlyusagemb"`
+	TrafficRemainingMB   int                `json:"trafficremainingmb"`
 
 	// other fields from Get, but not GetAll:
-	ACLs            []LoadBalancerACL            `json:"acl,omitempty"`
+	ACLs            []loadBalancerACL            `json:"acl,omitempty"`
 	Aliases         []string                     `json:"aliases"`
 	AllowDirectSSL  bool                         `json:"allowdirectssl"`
+	BackendSSL      bool                         `json:"backendssl"`
 	Backends        []loadBalancerBackend        `json:"backends"`
 	BalanceMode     LoadBalancerBalanceMode      `json:"balancemode"`
+	CheckHost       string                       `json:"checkhost"`
+	CheckMethod     string                       `json:"checkmethod"`
 	CheckMode       LoadBalancerCheckMode        `json:"checkmode"`
+	CheckStatus     internal.JSONInt             `json:"checkstatus"`
+	CheckURL        string                       `json:"checkurl"`
+	DiscoveryDNS    string                       `json:"discoverydns"`
+	DiscoveryMax    internal.JSONInt             `json:"discoverymax"`
 	HeadersPassOn   bool                         `json:"headerspasson"`
 	Ports           []internal.JSONInt           `json:"ports,omitempty"`
 	RefreshPatterns []loadBalancerRefreshPattern `json:"refreshpatterns"`
 	Regions         []RegionID                   `json:"regions"`
 	TLS             bool                         `json:"tls"`
-	// TODO "certificates", "listeners", "protocols", "extra"
+	Certificates    []loadBalancerCertificate    `json:"certificates,omitempty"`
+	// TODO "listeners", "protocols", "extra"
 }
 
 func (e existingLoadBalancer) ToLoadBalancer() LoadBalancer {
@@ -315,6 +702,7 @@ func (e existingLoadBalancer) ToLoadBalancer() LoadBalancer {
 		Type:                 e.Type,
 		HostSourceForceHost:  e.HostSourceForceHost,
 		BackendHostnameForce: e.BackendHostnameForce,
+		Status:               e.Status,
 		DateCreated:          time.Unix(int64(e.DateCreated), 0),
 		DateModified:         time.Unix(int64(e.DateModified), 0),
 		Scope:                e.Scope,
@@ -325,14 +713,37 @@ func (e existingLoadBalancer) ToLoadBalancer() LoadBalancer {
 		TrafficRemainingMB:   e.TrafficRemainingMB,
 		Version:              e.Version.Int(),
 
+		ACLs:             make([]LoadBalancerACL, len(e.ACLs)),
 		Aliases:          e.Aliases,
 		AutoUpgradeHTTPS: e.AutoUpgradeHTTPS,
 		Backends:         make([]LoadBalancerBackend, len(e.Backends)),
 		BalanceMode:      e.BalanceMode,
 		CheckMode:        e.CheckMode,
-		Ports:            internal.JSONIntSliceInt(e.Ports),
-		RefreshPatterns:  make([]LoadBalancerRefreshPattern, len(e.RefreshPatterns)),
-		Regions:          e.Regions,
+		Discovery: LoadBalancerDiscovery{
+			DNSName:     e.DiscoveryDNS,
+			MaxBackends: e.DiscoveryMax.Int(),
+		},
+		HealthCheck: LoadBalancerHealthCheck{
+			Mode:           e.CheckMode,
+			Host:           e.CheckHost,
+			Method:         e.CheckMethod,
+			Path:           e.CheckURL,
+			ExpectedStatus: e.CheckStatus.Int(),
+			BackendTLS:     e.BackendSSL,
+		},
+		Ports:           internal.JSONIntSliceInt(e.Ports),
+		RefreshPatterns: make([]LoadBalancerRefreshPattern, len(e.RefreshPatterns)),
+		Regions:         e.Regions,
+		TLS:             e.TLS,
+		AllowDirectSSL:  e.AllowDirectSSL,
+		Certificates:    make([]LoadBalancerCertificate, len(e.Certificates)),
+		loaded: &loadedLoadBalancer{
+			regions:        slices.Clone(e.Regions),
+			customerID:     e.CustomerID,
+			scope:          e.Scope,
+			scopeNetworkID: e.ScopeNetworkID,
+			checkMode:      e.CheckMode,
+		},
 	}
 
 	for idx, backend := range e.Backends {
@@ -343,6 +754,14 @@ func (e existingLoadBalancer) ToLoadBalancer() LoadBalancer {
 		lb.RefreshPatterns[idx] = pattern.ToLoadBalancerRefreshPattern()
 	}
 
+	for idx, acl := range e.ACLs {
+		lb.ACLs[idx] = acl.ToLoadBalancerACL()
+	}
+
+	for idx, cert := range e.Certificates {
+		lb.Certificates[idx] = cert.ToLoadBalancerCertificate()
+	}
+
 	return lb
 }
 
@@ -364,21 +783,22 @@ type loadBalancerDeleteRequest struct {
 type loadBalancerDeleteResponse = response
 
 type LoadBalancerFilter struct {
-	Id         LoadBalancerID `json:"id,omitempty"`
-	CustomerID CustomerID     `json:"customerid,omitempty"`
-	Name       string         `json:"name,omitempty"`
-	StdName    string         `json:"stdname,omitempty"`
-	Hostname   string         `json:"hostname,omitempty"`
-	// Status     string         `json:"status,omitempty"` // TODO LoadBalancerStatus enum DELETED,INACTIVE
-	// Type       []string       `json:"type,omitempty"` // TODO LoadBalancerType enum GLB,TCP,HTTP,UDP,CDN?
-	// LastModified time.Time      `json:"lastmodified,omitempty"` // TODO serialize as unixepoch
-	ResultStart  int `json:"resStart,omitempty"`
-	ResultWindow int `json:"resWindow,omitempty"`
+	Id           LoadBalancerID     `json:"id,omitempty"`
+	CustomerID   CustomerID         `json:"customerid,omitempty"`
+	Name         string             `json:"name,omitempty"`
+	StdName      string             `json:"stdname,omitempty"`
+	Hostname     string             `json:"hostname,omitempty"`
+	Status       LoadBalancerStatus `json:"status,omitempty"`
+	Type         []LoadBalancerType `json:"type,omitempty"`
+	LastModified time.Time          `json:"-"` // sent as unix epoch, see loadBalancerGetAllRequest
+	ResultStart  int                `json:"resStart,omitempty"`
+	ResultWindow int                `json:"resWindow,omitempty"`
 }
 
 type loadBalancerGetAllRequest struct {
 	legacyRequest
 	LoadBalancerFilter
+	LastModified int64 `json:"lastmodified,omitempty"` // unix epoch
 }
 
 type loadBalancerGetAllResponse struct {
@@ -389,14 +809,25 @@ type loadBalancerGetAllResponse struct {
 
 type loadBalancerCreateRequest struct {
 	legacyRequest
+	ACLs                 []loadBalancerACL            `json:"acl,omitempty"`
+	AllowDirectSSL       bool                         `json:"allowdirectssl,omitempty"`
 	Aliases              []string                     `json:"aliases,omitempty"`
 	AutoUpgradeHTTPS     bool                         `json:"autoupgradehttps,omitempty"`
 	BackendHostname      string                       `json:"backend_hostname,omitempty"`
 	BackendHostnameForce string                       `json:"backend_hostname_force,omitempty"`
+	BackendSSL           bool                         `json:"backendssl,omitempty"`
 	Backends             []loadBalancerBackend        `json:"backends,omitempty"`
 	BalanceMode          LoadBalancerBalanceMode      `json:"balancemode,omitempty"`
+	CheckHost            string                       `json:"checkhost,omitempty"`
+	CheckMethod          string                       `json:"checkmethod,omitempty"`
 	CheckMode            LoadBalancerCheckMode        `json:"checkmode,omitempty"`
+	CheckStatus          int                          `json:"checkstatus,omitempty"`
+	CheckURL             string                       `json:"checkurl,omitempty"`
 	CustomerID           CustomerID                   `json:"customerid,omitempty"`
+	DiscoveryDNS         string                       `json:"discoverydns,omitempty"`
+	DiscoveryMax         int                          `json:"discoverymax,omitempty"`
+	HostSource           string                       `json:"hostsource,omitempty"`
+	HostSourceForceHost  string                       `json:"hostsourceforcehost,omitempty"`
 	Name                 string                       `json:"name,omitempty"`
 	Ports                []int                        `json:"ports,omitempty"`
 	RefreshPatterns      []loadBalancerRefreshPattern `json:"refreshpatterns,omitempty"`
@@ -404,13 +835,8 @@ type loadBalancerCreateRequest struct {
 	Scope                LoadBalancerScope            `json:"scope,omitempty"`
 	ScopeInstances       int                          `json:"scope_instances,omitempty"`
 	ScopeNetworkID       NetworkID                    `json:"scope_networkid,omitempty"`
+	TLS                  bool                         `json:"tls,omitempty"`
 	Type                 LoadBalancerType             `json:"type,omitempty"`
-	// BackendSSL           bool                         `json:"backendssl,omitempty"`
-	// CheckHost            string                       `json:"checkhost,omitempty"`
-	// CheckMethod          string                       `json:"checkmethod,omitempty"`
-	// CheckURL             string                       `json:"checkurl,omitempty"`
-	// DiscoveryDNS         string                       `json:"discoverydns,omitempty"`
-	// DiscoveryMax         json.Number                  `json:"discoverymax,omitempty"`
 }
 
 type loadBalancerCreateResponse struct {
@@ -418,21 +844,42 @@ type loadBalancerCreateResponse struct {
 	LoadBalancer existingLoadBalancer `json:"loadbalancers"`
 }
 
+// loadBalancerUpdateRequest replaces every updatable field, so fields are not omitted when empty.
 type loadBalancerUpdateRequest struct {
 	legacyRequest
-	Backends       []loadBalancerBackend   `json:"backends"`
-	BalanceMode    LoadBalancerBalanceMode `json:"balancemode,omitempty"`
-	CheckMode      LoadBalancerCheckMode   `json:"checkmode,omitempty"`
-	Id             LoadBalancerID          `json:"id,omitempty"`
-	Name           string                  `json:"name,omitempty"`
-	Ports          []int                   `json:"ports"`
-	Scope          LoadBalancerScope       `json:"scope,omitempty"`
-	ScopeInstances int                     `json:"scope_instances,omitempty"`
-	ScopeNetworkID NetworkID               `json:"scope_networkid,omitempty"`
-	Type           LoadBalancerType        `json:"type,omitempty"`
-	// TODO all the other fields supported by loadbalancer.update
+	ACLs                 []loadBalancerACL            `json:"acl"`
+	AllowDirectSSL       bool                         `json:"allowdirectssl"`
+	Aliases              []string                     `json:"aliases"`
+	AutoUpgradeHTTPS     bool                         `json:"autoupgradehttps"`
+	BackendHostnameForce string                       `json:"backend_hostname_force"`
+	BackendSSL           bool                         `json:"backendssl"`
+	Backends             []loadBalancerBackend        `json:"backends"`
+	BalanceMode          LoadBalancerBalanceMode      `json:"balancemode,omitempty"`
+	CheckHost            string                       `json:"checkhost"`
+	CheckMethod          string                       `json:"checkmethod"`
+	CheckMode            LoadBalancerCheckMode        `json:"checkmode,omitempty"`
+	CheckStatus          int                          `json:"checkstatus"`
+	CheckURL             string                       `json:"checkurl"`
+	DiscoveryDNS         string                       `json:"discoverydns"`
+	DiscoveryMax         int                          `json:"discoverymax"`
+	HostSource           string                       `json:"hostsource"`
+	HostSourceForceHost  string                       `json:"hostsourceforcehost"`
+	Id                   LoadBalancerID               `json:"id,omitempty"`
+	Name                 string                       `json:"name,omitempty"`
+	Ports                []int                        `json:"ports"`
+	RefreshPatterns      []loadBalancerRefreshPattern `json:"refreshpatterns"`
+	Regions              []RegionID                   `json:"regions"`
+	Scope                LoadBalancerScope            `json:"scope,omitempty"`
+	ScopeInstances       int                          `json:"scope_instances,omitempty"`
+	ScopeNetworkID       NetworkID                    `json:"scope_networkid,omitempty"`
+	TLS                  bool                         `json:"tls"`
+	Type                 LoadBalancerType             `json:"type,omitempty"`
+	Version              int                          `json:"version,omitempty"`
 }
 
+// loadBalancerUpdateFuncMaxAttempts is how many times UpdateFunc tries get-modify-update on a version conflict.
+const loadBalancerUpdateFuncMaxAttempts = 5
+
 type loadBalancerUpdateResponse struct {
 	response
 	LoadBalancer existingLoadBalancer `json:"loadbalancers"`
@@ -443,7 +890,41 @@ type LoadBalancerClient interface {
 	Delete(ctx context.Context, id LoadBalancerID) error
 	Get(ctx context.Context, id LoadBalancerID) (*LoadBalancer, error)
 	GetAll(ctx context.Context, filter LoadBalancerFilter) ([]LoadBalancer, error)
+	// Update replaces every updatable field of the load balancer with the values in lb,
+	// so empty fields are cleared rather than left unchanged. Get the load balancer and modify it
+	// to leave fields unchanged.
 	Update(ctx context.Context, lb LoadBalancer) (*LoadBalancer, error)
+	// UpdateIfUnmodified is like Update, but fails with ErrConflict if the load balancer
+	// is no longer at lb.Version because it has been updated since it was read.
+	UpdateIfUnmodified(ctx context.Context, lb LoadBalancer) (*LoadBalancer, error)
+	// UpdateFunc applies fn to the current load balancer and updates it, retrying on ErrConflict.
+	UpdateFunc(ctx context.Context, id LoadBalancerID, fn func(lb *LoadBalancer) error) (*LoadBalancer, error)
+
+	AddACL(ctx context.Context, id LoadBalancerID, acl LoadBalancerACL) (*LoadBalancer, error)
+	RemoveACL(ctx context.Context, id LoadBalancerID, prefix netip.Prefix) (*LoadBalancer, error)
+
+	ListCertificates(ctx context.Context, id LoadBalancerID) ([]LoadBalancerCertificate, error)
+	UploadCertificate(ctx context.Context, id LoadBalancerID, certPEM []byte, keyPEM []byte, chainPEM []byte) (*LoadBalancerCertificate, error)
+	ReplaceCertificate(ctx context.Context, id LoadBalancerID, oldCertificateID LoadBalancerCertificateID, certPEM []byte, keyPEM []byte, chainPEM []byte) (*LoadBalancerCertificate, error)
+	RemoveCertificate(ctx context.Context, id LoadBalancerID, certificateID LoadBalancerCertificateID) error
+
+	AddBackend(ctx context.Context, id LoadBalancerID, backend LoadBalancerBackend) (*LoadBalancer, error)
+	RemoveBackend(ctx context.Context, id LoadBalancerID, nameOrUUID string) (*LoadBalancer, error)
+	SetBackendWeight(ctx context.Context, id LoadBalancerID, nameOrUUID string, weight int) (*LoadBalancer, error)
+	DrainBackend(ctx context.Context, id LoadBalancerID, nameOrUUID string, wait time.Duration) (*LoadBalancer, error)
+
+	// Apply executes a plan made by PlanLoadBalancer or PlanLoadBalancerDeletion.
+	Apply(ctx context.Context, plan LoadBalancerPlan) (*LoadBalancer, error)
+
+	// CreateLocalForDevice creates lb as a local scope load balancer on the network of the device,
+	// using the network of its first private IP or otherwise its primary IP.
+	CreateLocalForDevice(ctx context.Context, deviceID apiv2.DeviceID, lb LoadBalancer) (*LoadBalancer, error)
+
+	// Purge invalidates cached content of a CDN load balancer, returning the transaction doing the purge.
+	Purge(ctx context.Context, id LoadBalancerID, purge LoadBalancerPurge) (*Transaction, error)
+
+	// UsageReport reports the monthly traffic usage of every load balancer matching filter.
+	UsageReport(ctx context.Context, filter LoadBalancerFilter) (*LoadBalancerUsageReport, error)
 }
 
 type loadBalancerClient struct {
@@ -499,6 +980,9 @@ func (lbc *loadBalancerClient) GetAll(ctx context.Context, filter LoadBalancerFi
 		},
 		LoadBalancerFilter: filter,
 	}
+	if !filter.LastModified.IsZero() {
+		req.LastModified = filter.LastModified.Unix()
+	}
 	var resp loadBalancerGetAllResponse
 	err := lbc.c.httpLegacyJson(ctx, &req, &resp)
 	if err != nil {
@@ -518,58 +1002,59 @@ func (lbc *loadBalancerClient) Create(ctx context.Context, lb LoadBalancer) (*Lo
 	if !lb.ID.IsZero() {
 		return nil, fmt.Errorf("cannot create load balancer with specified ID")
 	}
+	if err := lb.Validate(); err != nil {
+		return nil, err
+	}
+	if err := lbc.c.Region().ValidateIDs(ctx, lb.Regions); err != nil {
+		return nil, err
+	}
+	if err := lbc.validateScopeNetwork(ctx, lb); err != nil {
+		return nil, err
+	}
 
 	req := loadBalancerCreateRequest{
 		legacyRequest: legacyRequest{
 			Command: "loadbalancer.create",
 		},
+		ACLs:                 make([]loadBalancerACL, len(lb.ACLs)),
+		AllowDirectSSL:       lb.AllowDirectSSL,
 		Aliases:              lb.Aliases,
 		AutoUpgradeHTTPS:     lb.AutoUpgradeHTTPS,
 		BackendHostnameForce: lb.BackendHostnameForce,
+		BackendSSL:           lb.HealthCheck.BackendTLS,
 		Backends:             make([]loadBalancerBackend, len(lb.Backends)),
 		BalanceMode:          lb.BalanceMode,
-		CheckMode:            lb.CheckMode,
+		CheckHost:            lb.HealthCheck.Host,
+		CheckMethod:          lb.HealthCheck.Method,
+		CheckMode:            lb.healthCheck().Mode,
+		CheckStatus:          lb.HealthCheck.ExpectedStatus,
+		CheckURL:             lb.HealthCheck.Path,
+		DiscoveryDNS:         lb.Discovery.DNSName,
+		DiscoveryMax:         lb.Discovery.MaxBackends,
 		CustomerID:           lb.CustomerID,
+		HostSource:           lb.HostSource,
+		HostSourceForceHost:  lb.HostSourceForceHost,
 		Name:                 lb.Name,
 		RefreshPatterns:      make([]loadBalancerRefreshPattern, len(lb.RefreshPatterns)),
 		Regions:              lb.Regions,
 		Scope:                lb.Scope,
 		ScopeInstances:       lb.ScopeInstances,
 		ScopeNetworkID:       lb.ScopeNetworkID,
+		TLS:                  lb.TLS,
 		Type:                 lb.Type,
 		Ports:                lb.Ports,
 	}
 
+	for idx, acl := range lb.ACLs {
+		req.ACLs[idx] = convertLoadBalancerACL(acl)
+	}
+
 	for idx, backend := range lb.Backends {
 		req.Backends[idx] = convertLoadBalancerBackend(backend)
 	}
 
 	for idx, pattern := range lb.RefreshPatterns {
-		req.RefreshPatterns[idx] = loadBalancerRefreshPattern{
-			ID:                         pattern.ID,
-			RegularExpression:          pattern.RegularExpression,
-			MinTTL:                     internal.JSONInt(pattern.MinTTL.Seconds()),
-			CacheTime:                  internal.JSONInt(pattern.CacheTime.Seconds()),
-			MaxTTL:                     internal.JSONInt(pattern.MaxTTL.Seconds()),
-			CheckTTL:                   internal.JSONInt(pattern.CheckTTL.Seconds()),
-			OverrideExpire:             pattern.OverrideExpire,
-			OverrideLastModified:       pattern.OverrideLastModified,
-			IgnoreSetCookie:            pattern.IgnoreSetCookie,
-			IgnoreCacheControl:         pattern.IgnoreCacheControl,
-			CacheAuthorizedPages:       pattern.CacheAuthorizedPages,
-			BrowserRefresh:             pattern.BrowserRefresh,
-			ForceExpireMins:            internal.JSONInt(pattern.ForceExpireMins.Minutes()),
-			PseudoStreamFLV:            pattern.PseudoStreamFLV,
-			PseudoStreamH264:           pattern.PseudoStreamH264,
-			CGIIgnoreParams:            pattern.CGIIgnoreParams,
-			NoCompression:              pattern.NoCompression,
-			RedirectCode:               internal.JSONInt(pattern.RedirectCode),
-			RedirectURL:                pattern.RedirectURL,
-			RedirectPreserveParams:     pattern.RedirectPreserveParams,
-			RedirectForceHTTPS:         pattern.RedirectForceHTTPS,
-			IPRestrictionDefaultPolicy: pattern.IPRestrictionDefaultPolicy,
-			IPRestrictions:             pattern.IPRestrictions,
-		}
+		req.RefreshPatterns[idx] = convertLoadBalancerRefreshPattern(pattern)
 	}
 
 	var resp loadBalancerCreateResponse
@@ -585,28 +1070,54 @@ func (lbc *loadBalancerClient) Create(ctx context.Context, lb LoadBalancer) (*Lo
 }
 
 func (lbc *loadBalancerClient) Update(ctx context.Context, lb LoadBalancer) (*LoadBalancer, error) {
+	return lbc.update(ctx, lb, false)
+}
+
+func (lbc *loadBalancerClient) UpdateIfUnmodified(ctx context.Context, lb LoadBalancer) (*LoadBalancer, error) {
+	return lbc.update(ctx, lb, true)
+}
+
+// UpdateFunc gets the load balancer, applies fn and updates it with UpdateIfUnmodified,
+// retrying from the get if someone else updated the load balancer in the meantime.
+func (lbc *loadBalancerClient) UpdateFunc(ctx context.Context, id LoadBalancerID, fn func(lb *LoadBalancer) error) (*LoadBalancer, error) {
+	for attempt := 1; ; attempt++ {
+		lb, err := lbc.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(lb); err != nil {
+			return nil, err
+		}
+		updated, err := lbc.UpdateIfUnmodified(ctx, *lb)
+		if errors.Is(err, ErrConflict) && attempt < loadBalancerUpdateFuncMaxAttempts {
+			lbc.c.debugLog(fmt.Sprintf("Rackcorp load balancer %d version %d conflict, retrying", id, lb.Version))
+			continue
+		}
+		return updated, err
+	}
+}
+
+// update sends lb to loadbalancer.update. When checkVersion is set the update is rejected with
+// ErrConflict if lb.Version is no longer the current version.
+func (lbc *loadBalancerClient) update(ctx context.Context, lb LoadBalancer, checkVersion bool) (*LoadBalancer, error) {
 	if lb.ID.IsZero() {
 		return nil, fmt.Errorf("cannot update load balancer without ID")
 	}
-
-	req := loadBalancerUpdateRequest{
-		legacyRequest: legacyRequest{
-			Command: "loadbalancer.update",
-		},
-		Backends:       make([]loadBalancerBackend, len(lb.Backends)),
-		BalanceMode:    lb.BalanceMode,
-		CheckMode:      lb.CheckMode,
-		Id:             lb.ID,
-		Name:           lb.Name,
-		Ports:          lb.Ports,
-		Scope:          lb.Scope,
-		ScopeInstances: lb.ScopeInstances,
-		ScopeNetworkID: lb.ScopeNetworkID,
-		Type:           lb.Type,
+	if err := lb.Validate(); err != nil {
+		return nil, err
+	}
+	if err := lbc.c.Region().ValidateIDs(ctx, lb.addedRegions()); err != nil {
+		return nil, err
+	}
+	if lb.scopeNetworkChanged() {
+		if err := lbc.validateScopeNetwork(ctx, lb); err != nil {
+			return nil, err
+		}
 	}
 
-	for idx, backend := range lb.Backends {
-		req.Backends[idx] = convertLoadBalancerBackend(backend)
+	req := newLoadBalancerUpdateRequest(lb)
+	if checkVersion {
+		req.Version = lb.Version
 	}
 
 	var resp loadBalancerUpdateResponse
@@ -615,9 +1126,109 @@ func (lbc *loadBalancerClient) Update(ctx context.Context, lb LoadBalancer) (*Lo
 		return nil, err
 	}
 	if !resp.IsOK() {
+		if checkVersion && resp.isVersionConflict() {
+			return nil, newApiError(resp.response, ErrConflict)
+		}
 		return nil, newApiError(resp.response, nil)
 	}
 	newLb := resp.LoadBalancer.ToLoadBalancer()
 	return &newLb, nil
+}
 
+func newLoadBalancerUpdateRequest(lb LoadBalancer) loadBalancerUpdateRequest {
+	req := loadBalancerUpdateRequest{
+		legacyRequest: legacyRequest{
+			Command: "loadbalancer.update",
+		},
+		ACLs:                 make([]loadBalancerACL, len(lb.ACLs)),
+		AllowDirectSSL:       lb.AllowDirectSSL,
+		Aliases:              lb.Aliases,
+		AutoUpgradeHTTPS:     lb.AutoUpgradeHTTPS,
+		BackendHostnameForce: lb.BackendHostnameForce,
+		BackendSSL:           lb.HealthCheck.BackendTLS,
+		Backends:             make([]loadBalancerBackend, len(lb.Backends)),
+		BalanceMode:          lb.BalanceMode,
+		CheckHost:            lb.HealthCheck.Host,
+		CheckMethod:          lb.HealthCheck.Method,
+		CheckMode:            lb.healthCheck().Mode,
+		CheckStatus:          lb.HealthCheck.ExpectedStatus,
+		CheckURL:             lb.HealthCheck.Path,
+		DiscoveryDNS:         lb.Discovery.DNSName,
+		DiscoveryMax:         lb.Discovery.MaxBackends,
+		HostSource:           lb.HostSource,
+		HostSourceForceHost:  lb.HostSourceForceHost,
+		Id:                   lb.ID,
+		Name:                 lb.Name,
+		Ports:                lb.Ports,
+		RefreshPatterns:      make([]loadBalancerRefreshPattern, len(lb.RefreshPatterns)),
+		Regions:              lb.Regions,
+		Scope:                lb.Scope,
+		ScopeInstances:       lb.ScopeInstances,
+		ScopeNetworkID:       lb.ScopeNetworkID,
+		TLS:                  lb.TLS,
+		Type:                 lb.Type,
+	}
+
+	// Send empty lists rather than null so that clearing a list is explicit.
+	if req.Aliases == nil {
+		req.Aliases = []string{}
+	}
+	if req.Ports == nil {
+		req.Ports = []int{}
+	}
+	if req.Regions == nil {
+		req.Regions = []RegionID{}
+	}
+
+	for idx, acl := range lb.ACLs {
+		req.ACLs[idx] = convertLoadBalancerACL(acl)
+	}
+
+	for idx, backend := range lb.Backends {
+		req.Backends[idx] = convertLoadBalancerBackend(backend)
+	}
+
+	for idx, pattern := range lb.RefreshPatterns {
+		req.RefreshPatterns[idx] = convertLoadBalancerRefreshPattern(pattern)
+	}
+
+	return req
+}
+
+// AddACL adds acl to the load balancer, replacing any existing entry for the same prefix.
+func (lbc *loadBalancerClient) AddACL(ctx context.Context, id LoadBalancerID, acl LoadBalancerACL) (*LoadBalancer, error) {
+	if err := acl.Validate(); err != nil {
+		return nil, err
+	}
+
+	return lbc.UpdateFunc(ctx, id, func(lb *LoadBalancer) error {
+		entry := acl
+		acls := make([]LoadBalancerACL, 0, len(lb.ACLs)+1)
+		for _, existing := range lb.ACLs {
+			if existing.dataString() == acl.dataString() {
+				entry.ID = existing.ID
+				continue
+			}
+			acls = append(acls, existing)
+		}
+		lb.ACLs = append(acls, entry)
+		return nil
+	})
+}
+
+// RemoveACL removes the ACL entry for prefix from the load balancer.
+func (lbc *loadBalancerClient) RemoveACL(ctx context.Context, id LoadBalancerID, prefix netip.Prefix) (*LoadBalancer, error) {
+	return lbc.UpdateFunc(ctx, id, func(lb *LoadBalancer) error {
+		acls := make([]LoadBalancerACL, 0, len(lb.ACLs))
+		for _, existing := range lb.ACLs {
+			if existing.dataString() != prefix.String() {
+				acls = append(acls, existing)
+			}
+		}
+		if len(acls) == len(lb.ACLs) {
+			return fmt.Errorf("load balancer %d has no ACL for %s", id, prefix)
+		}
+		lb.ACLs = acls
+		return nil
+	})
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// findBackend returns the index of the backend with the given UUID, or failing that the given name, or -1.
+func findBackend(backends []LoadBalancerBackend, nameOrUUID string) int {
+	for idx, backend := range backends {
+		if backend.UUID != "" && backend.UUID == nameOrUUID {
+			return idx
+		}
+	}
+	for idx, backend := range backends {
+		if backend.Name == nameOrUUID {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (lbc *loadBalancerClient) AddBackend(ctx context.Context, id LoadBalancerID, backend LoadBalancerBackend) (*LoadBalancer, error) {
+	if backend.Name == "" {
+		return nil, errors.New("backend name is required")
+	}
+	return lbc.UpdateFunc(ctx, id, func(lb *LoadBalancer) error {
+		if findBackend(lb.Backends, backend.Name) >= 0 || (backend.UUID != "" && findBackend(lb.Backends, backend.UUID) >= 0) {
+			return fmt.Errorf("load balancer %d already has backend %q", id, backend.Name)
+		}
+		lb.Backends = append(lb.Backends, backend)
+		return nil
+	})
+}
+
+func (lbc *loadBalancerClient) RemoveBackend(ctx context.Context, id LoadBalancerID, nameOrUUID string) (*LoadBalancer, error) {
+	return lbc.UpdateFunc(ctx, id, func(lb *LoadBalancer) error {
+		idx := findBackend(lb.Backends, nameOrUUID)
+		if idx < 0 {
+			return fmt.Errorf("load balancer %d has no backend %q", id, nameOrUUID)
+		}
+		lb.Backends = append(lb.Backends[:idx:idx], lb.Backends[idx+1:]...)
+		return nil
+	})
+}
+
+// SetBackendWeight sets the weight of the backend. A weight of zero drains it.
+func (lbc *loadBalancerClient) SetBackendWeight(ctx context.Context, id LoadBalancerID, nameOrUUID string, weight int) (*LoadBalancer, error) {
+	if weight < 0 {
+		return nil, fmt.Errorf("backend weight %d must not be negative", weight)
+	}
+	return lbc.UpdateFunc(ctx, id, func(lb *LoadBalancer) error {
+		idx := findBackend(lb.Backends, nameOrUUID)
+		if idx < 0 {
+			return fmt.Errorf("load balancer %d has no backend %q", id, nameOrUUID)
+		}
+		lb.Backends[idx].Weight = weight
+		lb.Backends[idx].Drained = weight == 0
+		return nil
+	})
+}
+
+// DrainBackend sets the backend weight to zero so it receives no new traffic, then waits for wait
+// to let existing connections finish before returning.
+func (lbc *loadBalancerClient) DrainBackend(ctx context.Context, id LoadBalancerID, nameOrUUID string, wait time.Duration) (*LoadBalancer, error) {
+	lb, err := lbc.SetBackendWeight(ctx, id, nameOrUUID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if wait <= 0 {
+		return lb, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return lb, fmt.Errorf("failed waiting for backend %q to drain: %w", nameOrUUID, ctx.Err())
+	case <-timer.C:
+		return lb, nil
+	}
+}
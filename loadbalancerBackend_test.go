@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalancerDrainBackendRetriesOnConflict(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := getTestDataString(t, "loadbalancer.get.responseBody.json")
+
+	var conflictReq, req loadBalancerUpdateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &conflictReq)).
+		Reply(200).
+		BodyString(`{"code":"CONFLICT","message":"Load balancer has been modified"}`)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(responseBody)
+
+	_, err := client.LoadBalancer().DrainBackend(context.TODO(), 1234, "5d1c9a54-6f0e-4c43-9f4e-0b7a3c1d2e02", 0)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "DrainBackend error")
+
+	assert.Equal(t, 7, conflictReq.Version, "conflicting update version")
+	assert.Equal(t, 7, req.Version, "retried update version")
+	require.Len(t, req.Backends, 2, "Backends")
+	require.NotNil(t, req.Backends[0].Weight, "Backends[0].Weight")
+	assert.Equal(t, 100, req.Backends[0].Weight.Int(), "Backends[0].Weight")
+	require.NotNil(t, req.Backends[1].Weight, "Backends[1].Weight must be sent to drain")
+	assert.Equal(t, 0, req.Backends[1].Weight.Int(), "Backends[1].Weight")
+}
+
+func TestLoadBalancerAddBackend(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := getTestDataString(t, "loadbalancer.get.responseBody.json")
+
+	var req loadBalancerUpdateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(responseBody)
+
+	_, err := client.LoadBalancer().AddBackend(context.TODO(), 1234, LoadBalancerBackend{
+		Name:     "origin3",
+		Hostname: "192.0.2.12",
+		Port:     443,
+		TLS:      true,
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "AddBackend error")
+
+	require.Len(t, req.Backends, 3, "Backends")
+	assert.Equal(t, "origin3", req.Backends[2].Name, "Backends[2].Name")
+	assert.Nil(t, req.Backends[2].Weight, "unset Backends[2].Weight must not drain the backend")
+}
+
+func TestLoadBalancerBackendWeightJSON(t *testing.T) {
+	body, err := json.Marshal(convertLoadBalancerBackend(LoadBalancerBackend{Name: "web1"}))
+	require.NoError(t, err, "json.Marshal")
+	assert.NotContains(t, string(body), `"weight"`, "unset Weight is omitted")
+
+	body, err = json.Marshal(convertLoadBalancerBackend(LoadBalancerBackend{Name: "web1", Drained: true}))
+	require.NoError(t, err, "json.Marshal")
+	assert.Contains(t, string(body), `"weight":0`, "Drained sends a weight of zero")
+
+	var backend loadBalancerBackend
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"web1","weight":"0"}`), &backend), "json.Unmarshal")
+	assert.True(t, backend.ToLoadBalancerBackend().Drained, "a weight of zero from Rackcorp is Drained")
+}
+
+func TestLoadBalancerRemoveBackendMissing(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(getTestDataString(t, "loadbalancer.get.responseBody.json"))
+
+	_, err := client.LoadBalancer().RemoveBackend(context.TODO(), 1234, "origin9")
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.Error(t, err, "RemoveBackend of missing backend")
+}
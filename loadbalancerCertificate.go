@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rackcorpcloud/rackcorp-api-go/internal"
+)
+
+type LoadBalancerCertificateID int
+
+func (id *LoadBalancerCertificateID) UnmarshalJSON(data []byte) error {
+	return internal.UnmarshalJSONInt(id, data)
+}
+
+// LoadBalancerCertificate is a TLS certificate attached to a load balancer.
+// Subject, DNSNames, NotBefore and NotAfter are parsed from the leaf certificate. If the PEM stored
+// by Rackcorp could not be parsed they are left zero and ParseError is set, rather than failing Get.
+type LoadBalancerCertificate struct {
+	ID             LoadBalancerCertificateID
+	CertificatePEM string
+	ChainPEM       string
+	Subject        string
+	DNSNames       []string
+	NotBefore      time.Time
+	NotAfter       time.Time
+	ParseError     error
+}
+
+type loadBalancerCertificate struct {
+	ID          LoadBalancerCertificateID `json:"id,omitempty"`
+	Certificate string                    `json:"certificate,omitempty"`
+	PrivateKey  string                    `json:"privatekey,omitempty"`
+	Chain       string                    `json:"chain,omitempty"`
+}
+
+func (c loadBalancerCertificate) ToLoadBalancerCertificate() LoadBalancerCertificate {
+	cert := LoadBalancerCertificate{
+		ID:             c.ID,
+		CertificatePEM: c.Certificate,
+		ChainPEM:       c.Chain,
+	}
+	certPEM := c.Certificate
+	if certPEM == "" {
+		// only a chain is stored, its first certificate is the leaf
+		certPEM = c.Chain
+	}
+	leaf, err := parseLeafCertificate([]byte(certPEM))
+	if err != nil {
+		cert.ParseError = fmt.Errorf("failed to parse load balancer certificate %d: %w", c.ID, err)
+		return cert
+	}
+	cert.Subject = leaf.Subject.String()
+	cert.DNSNames = leaf.DNSNames
+	cert.NotBefore = leaf.NotBefore
+	cert.NotAfter = leaf.NotAfter
+	return cert
+}
+
+// parseLeafCertificate parses the first certificate in certPEM, skipping any other PEM blocks.
+func parseLeafCertificate(certPEM []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			return nil, errors.New("no PEM encoded certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+type loadBalancerCertificateCreateRequest struct {
+	legacyRequest
+	LoadBalancerID LoadBalancerID `json:"loadbalancerid"`
+	loadBalancerCertificate
+}
+
+type loadBalancerCertificateCreateResponse struct {
+	response
+	Certificate loadBalancerCertificate `json:"certificate"`
+}
+
+type loadBalancerCertificateDeleteRequest struct {
+	legacyRequest
+	LoadBalancerID LoadBalancerID            `json:"loadbalancerid"`
+	ID             LoadBalancerCertificateID `json:"id"`
+}
+
+type loadBalancerCertificateDeleteResponse = response
+
+func (lbc *loadBalancerClient) ListCertificates(ctx context.Context, id LoadBalancerID) ([]LoadBalancerCertificate, error) {
+	lb, err := lbc.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return lb.Certificates, nil
+}
+
+// UploadCertificate attaches a PEM encoded certificate, private key and optional chain to the load balancer.
+// The key pair is checked locally before it is uploaded.
+func (lbc *loadBalancerClient) UploadCertificate(ctx context.Context, id LoadBalancerID, certPEM []byte, keyPEM []byte, chainPEM []byte) (*LoadBalancerCertificate, error) {
+	if id.IsZero() {
+		return nil, errors.New("id parameter is required")
+	}
+
+	fullChain := append(append([]byte{}, certPEM...), chainPEM...)
+	if _, err := tls.X509KeyPair(fullChain, keyPEM); err != nil {
+		return nil, fmt.Errorf("invalid certificate key pair: %w", err)
+	}
+
+	req := loadBalancerCertificateCreateRequest{
+		legacyRequest: legacyRequest{
+			Command: "loadbalancer.certificate.create",
+		},
+		LoadBalancerID: id,
+		loadBalancerCertificate: loadBalancerCertificate{
+			Certificate: string(certPEM),
+			PrivateKey:  string(keyPEM),
+			Chain:       string(chainPEM),
+		},
+	}
+	var resp loadBalancerCertificateCreateResponse
+	err := lbc.c.httpLegacyJson(ctx, &req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsOK() {
+		return nil, newApiError(resp.response, nil)
+	}
+
+	if resp.Certificate.Certificate == "" {
+		resp.Certificate.Certificate = string(certPEM)
+		resp.Certificate.Chain = string(chainPEM)
+	}
+	cert := resp.Certificate.ToLoadBalancerCertificate()
+	return &cert, nil
+}
+
+// ReplaceCertificate uploads a new certificate and then removes the old one, so the load balancer
+// is never left without a certificate.
+func (lbc *loadBalancerClient) ReplaceCertificate(ctx context.Context, id LoadBalancerID, oldCertificateID LoadBalancerCertificateID, certPEM []byte, keyPEM []byte, chainPEM []byte) (*LoadBalancerCertificate, error) {
+	cert, err := lbc.UploadCertificate(ctx, id, certPEM, keyPEM, chainPEM)
+	if err != nil {
+		return nil, err
+	}
+	err = lbc.RemoveCertificate(ctx, id, oldCertificateID)
+	if err != nil {
+		return cert, fmt.Errorf("uploaded certificate %d but failed to remove certificate %d: %w", cert.ID, oldCertificateID, err)
+	}
+	return cert, nil
+}
+
+func (lbc *loadBalancerClient) RemoveCertificate(ctx context.Context, id LoadBalancerID, certificateID LoadBalancerCertificateID) error {
+	if id.IsZero() {
+		return errors.New("id parameter is required")
+	}
+	if certificateID == 0 {
+		return errors.New("certificateID parameter is required")
+	}
+
+	req := loadBalancerCertificateDeleteRequest{
+		legacyRequest: legacyRequest{
+			Command: "loadbalancer.certificate.delete",
+		},
+		LoadBalancerID: id,
+		ID:             certificateID,
+	}
+	var resp loadBalancerCertificateDeleteResponse
+	err := lbc.c.httpLegacyJson(ctx, &req, &resp)
+	if err != nil {
+		return err
+	}
+	if !resp.IsOK() {
+		return newApiError(resp, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateTestCertificate(t *testing.T, notAfter time.Time, dnsNames ...string) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "GenerateKey")
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    notAfter.AddDate(0, -3, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err, "CreateCertificate")
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err, "MarshalECPrivateKey")
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return certPEM, keyPEM
+}
+
+func TestLoadBalancerUploadCertificate(t *testing.T) {
+	defer gock.OffAll()
+
+	notAfter := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	certPEM, keyPEM := generateTestCertificate(t, notAfter, "www.example.com", "static.example.com")
+
+	client := getTestClient(t)
+
+	var req loadBalancerCertificateCreateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.certificate.create", &req)).
+		Reply(200).
+		BodyString(`{"certificate":{"id":"31"},"code":"OK","message":"Certificate created"}`)
+
+	cert, err := client.LoadBalancer().UploadCertificate(context.TODO(), 1234, certPEM, keyPEM, nil)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "UploadCertificate error")
+
+	assert.Equal(t, LoadBalancerID(1234), req.LoadBalancerID, "request LoadBalancerID")
+	assert.Equal(t, string(certPEM), req.Certificate, "request Certificate")
+	assert.Equal(t, string(keyPEM), req.PrivateKey, "request PrivateKey")
+
+	assert.Equal(t, LoadBalancerCertificateID(31), cert.ID, "ID")
+	assert.Equal(t, []string{"www.example.com", "static.example.com"}, cert.DNSNames, "DNSNames")
+	assert.True(t, notAfter.Equal(cert.NotAfter), "NotAfter")
+}
+
+func TestLoadBalancerUploadCertificateMismatchedKey(t *testing.T) {
+	certPEM, _ := generateTestCertificate(t, time.Now().AddDate(0, 1, 0), "www.example.com")
+	_, keyPEM := generateTestCertificate(t, time.Now().AddDate(0, 1, 0), "www.example.com")
+
+	client := getTestClient(t)
+
+	_, err := client.LoadBalancer().UploadCertificate(context.TODO(), 1234, certPEM, keyPEM, nil)
+	assert.Error(t, err, "UploadCertificate with mismatched key")
+}
+
+func TestLoadBalancerCertificatesDecodeTolerant(t *testing.T) {
+	expired := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	expiredPEM, _ := generateTestCertificate(t, expired, "old.example.com")
+	chainPEM, _ := generateTestCertificate(t, time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC), "chain.example.com")
+
+	e := existingLoadBalancer{
+		ID: 1234,
+		Certificates: []loadBalancerCertificate{
+			{ID: 31, Certificate: string(expiredPEM)},
+			{ID: 32, Certificate: "-----BEGIN CERTIFICATE-----\nnot base64\n-----END CERTIFICATE-----\n"},
+			{ID: 33, Chain: string(chainPEM)},
+		},
+	}
+	lb := e.ToLoadBalancer()
+	require.Len(t, lb.Certificates, 3, "Certificates")
+
+	assert.NoError(t, lb.Certificates[0].ParseError, "expired ParseError")
+	assert.True(t, expired.Equal(lb.Certificates[0].NotAfter), "expired NotAfter")
+
+	assert.Error(t, lb.Certificates[1].ParseError, "malformed ParseError")
+	assert.Equal(t, LoadBalancerCertificateID(32), lb.Certificates[1].ID, "malformed ID")
+
+	assert.NoError(t, lb.Certificates[2].ParseError, "chain only ParseError")
+	assert.Equal(t, []string{"chain.example.com"}, lb.Certificates[2].DNSNames, "chain only DNSNames")
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHAProxyCertificatePath = "/usr/local/etc/haproxy/certs/"
+	defaultHAProxyTimeout         = 30 * time.Second
+	defaultHAProxyCacheMaxAge     = time.Hour
+)
+
+// HAProxyOptions adjusts the HAProxy configuration rendered by RenderHAProxyConfig for the local environment.
+type HAProxyOptions struct {
+	// CertificatePath is the certificate file or directory used for TLS frontends,
+	// defaulting to /usr/local/etc/haproxy/certs/.
+	CertificatePath string
+	// TLSPorts are the frontend ports that terminate TLS when LoadBalancer.TLS is set, defaulting to 443.
+	TLSPorts []int
+}
+
+// RenderHAProxyConfig writes an HAProxy configuration equivalent to lb, with a frontend and backend per port.
+// It is intended for local testing, so only the listening, balancing, health check and backend settings are
+// rendered. GLB load balancers are rendered as TCP proxies to every backend, as the DNS based selection of a
+// backend has no HAProxy equivalent. UDP load balancers are not supported by HAProxy.
+func RenderHAProxyConfig(w io.Writer, lb LoadBalancer, opts HAProxyOptions) error {
+	if opts.CertificatePath == "" {
+		opts.CertificatePath = defaultHAProxyCertificatePath
+	}
+	if opts.TLSPorts == nil {
+		opts.TLSPorts = []int{443}
+	}
+
+	var mode string
+	switch lb.Type {
+	case LoadBalancerTypeHTTP, LoadBalancerTypeCDN:
+		mode = "http"
+	case LoadBalancerTypeTCP, LoadBalancerTypeGLB:
+		mode = "tcp"
+	default:
+		return fmt.Errorf("load balancer type %q cannot be rendered as HAProxy configuration", lb.Type)
+	}
+	if len(lb.Ports) == 0 {
+		return fmt.Errorf("load balancer %q has no ports", lb.Name)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Rendered from %s load balancer %q (%d)\n", lb.Type, lb.Name, lb.ID)
+	sb.WriteString("global\n")
+	sb.WriteString("    log stdout format raw local0\n")
+	sb.WriteString("\n")
+	sb.WriteString("defaults\n")
+	fmt.Fprintf(&sb, "    mode %s\n", mode)
+	sb.WriteString("    log global\n")
+	fmt.Fprintf(&sb, "    option %slog\n", mode)
+	sb.WriteString("    timeout connect 5s\n")
+	fmt.Fprintf(&sb, "    timeout client %s\n", formatHAProxyDuration(defaultHAProxyTimeout))
+	fmt.Fprintf(&sb, "    timeout server %s\n", formatHAProxyDuration(defaultHAProxyTimeout))
+
+	if !lb.Discovery.IsZero() {
+		sb.WriteString("\n")
+		sb.WriteString("resolvers dns\n")
+		sb.WriteString("    parse-resolv-conf\n")
+	}
+
+	if lb.Type == LoadBalancerTypeCDN {
+		maxAge := defaultHAProxyCacheMaxAge
+		for _, pattern := range lb.RefreshPatterns {
+			maxAge = max(maxAge, pattern.MaxTTL)
+		}
+		sb.WriteString("\n")
+		sb.WriteString("cache cdn\n")
+		sb.WriteString("    total-max-size 256\n")
+		fmt.Fprintf(&sb, "    max-age %d\n", int(maxAge.Seconds()))
+	}
+
+	for _, port := range lb.Ports {
+		tls := lb.TLS && slices.Contains(opts.TLSPorts, port)
+
+		sb.WriteString("\n")
+		fmt.Fprintf(&sb, "frontend fe_%d\n", port)
+		if tls {
+			fmt.Fprintf(&sb, "    bind :%d ssl crt %s\n", port, opts.CertificatePath)
+		} else {
+			fmt.Fprintf(&sb, "    bind :%d\n", port)
+		}
+		if mode == "http" {
+			if lb.TLS && lb.AutoUpgradeHTTPS && !tls {
+				sb.WriteString("    http-request redirect scheme https code 301\n")
+			}
+			if lb.Type == LoadBalancerTypeCDN {
+				sb.WriteString("    http-request cache-use cdn\n")
+				sb.WriteString("    http-response cache-store cdn\n")
+			}
+		}
+		fmt.Fprintf(&sb, "    default_backend be_%d\n", port)
+
+		sb.WriteString("\n")
+		fmt.Fprintf(&sb, "backend be_%d\n", port)
+		renderHAProxyBackend(&sb, lb, mode, port)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func renderHAProxyBackend(sb *strings.Builder, lb LoadBalancer, mode string, port int) {
+	switch lb.BalanceMode {
+	case LoadBalancerBalanceModeLeast:
+		sb.WriteString("    balance leastconn\n")
+	case LoadBalancerBalanceModeRandom:
+		sb.WriteString("    balance random\n")
+	default:
+		sb.WriteString("    balance roundrobin\n")
+	}
+
+	if mode == "http" && lb.HostSource == "FORCE" && lb.HostSourceForceHost != "" {
+		fmt.Fprintf(sb, "    http-request set-header Host %s\n", lb.HostSourceForceHost)
+	}
+
+	hc := lb.healthCheck()
+	if mode == "http" && hc.Mode == LoadBalancerCheckModeHTTP {
+		sb.WriteString("    option httpchk\n")
+		method := hc.Method
+		if method == "" {
+			method = "GET"
+		}
+		path := hc.Path
+		if path == "" {
+			path = "/"
+		}
+		fmt.Fprintf(sb, "    http-check send meth %s uri %s", method, path)
+		if hc.Host != "" {
+			fmt.Fprintf(sb, " hdr Host %s", hc.Host)
+		}
+		sb.WriteString("\n")
+		if hc.ExpectedStatus != 0 {
+			fmt.Fprintf(sb, "    http-check expect status %d\n", hc.ExpectedStatus)
+		}
+	}
+
+	var timeout time.Duration
+	for _, backend := range lb.Backends {
+		timeout = max(timeout, backend.Timeout)
+	}
+	if timeout != 0 {
+		fmt.Fprintf(sb, "    timeout server %s\n", formatHAProxyDuration(timeout))
+	}
+
+	if !lb.Discovery.IsZero() {
+		maxBackends := lb.Discovery.MaxBackends
+		if maxBackends == 0 {
+			maxBackends = 10
+		}
+		fmt.Fprintf(sb, "    server-template discovered %d %s:%d check resolvers dns init-addr none", maxBackends, lb.Discovery.DNSName, port)
+		if hc.BackendTLS {
+			sb.WriteString(" ssl verify none")
+		}
+		sb.WriteString("\n")
+	}
+
+	for idx, backend := range lb.Backends {
+		if len(backend.PortMask) > 0 && !slices.Contains(backend.PortMask, port) {
+			continue
+		}
+		backendPort := backend.Port
+		if backendPort == 0 {
+			backendPort = port
+		}
+		fmt.Fprintf(sb, "    server %s %s:%d", haproxyServerName(backend.Name, idx), backend.Hostname, backendPort)
+		if backend.Weight != 0 || backend.Drained {
+			fmt.Fprintf(sb, " weight %d", backend.Weight)
+		}
+		sb.WriteString(" check")
+		if backend.TLS || hc.BackendTLS {
+			sb.WriteString(" ssl verify none")
+		}
+		if backend.TCPProxy == LoadBalancerTCPProxyModeV2 {
+			sb.WriteString(" send-proxy-v2")
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// haproxyServerName returns name with the characters HAProxy does not allow in a server name replaced,
+// suffixed with the backend index so that names stay unique within the backend section after replacement.
+func haproxyServerName(name string, idx int) string {
+	if name == "" {
+		name = "server"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name) + fmt.Sprintf("_%d", idx)
+}
+
+func formatHAProxyDuration(d time.Duration) string {
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
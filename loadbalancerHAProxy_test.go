@@ -0,0 +1,116 @@
+package api
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+func TestRenderHAProxyConfig(t *testing.T) {
+	cdn := getTestLoadBalancer(t)
+
+	tests := []struct {
+		name string
+		lb   LoadBalancer
+	}{
+		{
+			name: "cdn",
+			lb:   cdn,
+		},
+		{
+			name: "http",
+			lb: LoadBalancer{
+				ID:               101,
+				Name:             "web",
+				Type:             LoadBalancerTypeHTTP,
+				Ports:            []int{80, 443},
+				TLS:              true,
+				AutoUpgradeHTTPS: true,
+				BalanceMode:      LoadBalancerBalanceModeLeast,
+				HealthCheck: LoadBalancerHealthCheck{
+					Mode:           LoadBalancerCheckModeHTTP,
+					Method:         "HEAD",
+					Path:           "/healthz",
+					ExpectedStatus: 204,
+				},
+				Backends: []LoadBalancerBackend{
+					{Name: "web 1", Hostname: "10.0.0.11", Port: 8080, Weight: 100, Timeout: 60 * time.Second},
+					{Name: "web2", Hostname: "10.0.0.12", Port: 8080, Drained: true},
+				},
+			},
+		},
+		{
+			name: "tcp",
+			lb: LoadBalancer{
+				ID:          102,
+				Name:        "db",
+				Type:        LoadBalancerTypeTCP,
+				Ports:       []int{5432, 5433},
+				BalanceMode: LoadBalancerBalanceModeRandom,
+				HealthCheck: LoadBalancerHealthCheck{Mode: LoadBalancerCheckModeTCP},
+				Backends: []LoadBalancerBackend{
+					{Name: "primary", Hostname: "10.0.1.10", Weight: 100, TCPProxy: LoadBalancerTCPProxyModeV2, PortMask: []int{5432}},
+					{Name: "replica", Hostname: "10.0.1.11", Weight: 100, TLS: true, PortMask: []int{5433}},
+				},
+			},
+		},
+		{
+			name: "names",
+			lb: LoadBalancer{
+				ID:          104,
+				Name:        "names",
+				Type:        LoadBalancerTypeHTTP,
+				Ports:       []int{80},
+				HealthCheck: LoadBalancerHealthCheck{Mode: LoadBalancerCheckModeTCP},
+				Backends: []LoadBalancerBackend{
+					{Name: "web 1", Hostname: "10.0.2.11"},
+					{Name: "web/1", Hostname: "10.0.2.12"},
+					{Name: "web_1", Hostname: "10.0.2.13"},
+					{Hostname: "10.0.2.14"},
+				},
+			},
+		},
+		{
+			name: "glb",
+			lb: LoadBalancer{
+				ID:    103,
+				Name:  "global",
+				Type:  LoadBalancerTypeGLB,
+				Ports: []int{443},
+				Discovery: LoadBalancerDiscovery{
+					DNSName:     "origins.example.com",
+					MaxBackends: 4,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			err := RenderHAProxyConfig(&sb, tt.lb, HAProxyOptions{})
+			require.NoError(t, err, "RenderHAProxyConfig error")
+
+			golden := filepath.Join("testdata", "loadbalancer.haproxy."+tt.name+".cfg")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, []byte(sb.String()), 0o600), "os.WriteFile")
+			}
+			assert.Equal(t, getTestDataString(t, filepath.Base(golden)), sb.String(), "rendered configuration")
+		})
+	}
+
+	t.Run("udp", func(t *testing.T) {
+		var sb strings.Builder
+		err := RenderHAProxyConfig(&sb, LoadBalancer{Name: "dns", Type: LoadBalancerTypeUDP, Ports: []int{53}}, HAProxyOptions{})
+		assert.Error(t, err, "RenderHAProxyConfig error")
+		assert.Empty(t, sb.String(), "rendered configuration")
+	})
+}
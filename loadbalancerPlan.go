@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type LoadBalancerPlanAction string
+
+const (
+	LoadBalancerPlanActionNone   LoadBalancerPlanAction = "none"
+	LoadBalancerPlanActionCreate LoadBalancerPlanAction = "create"
+	LoadBalancerPlanActionUpdate LoadBalancerPlanAction = "update"
+	LoadBalancerPlanActionDelete LoadBalancerPlanAction = "delete"
+)
+
+type LoadBalancerChangeKind string
+
+const (
+	LoadBalancerChangeAdd    LoadBalancerChangeKind = "+"
+	LoadBalancerChangeRemove LoadBalancerChangeKind = "-"
+	LoadBalancerChangeModify LoadBalancerChangeKind = "~"
+)
+
+// LoadBalancerFieldChange is a single field level difference. Field is a path such as
+// "name", "aliases" or "backends[origin1].weight".
+type LoadBalancerFieldChange struct {
+	Kind  LoadBalancerChangeKind
+	Field string
+	Old   string
+	New   string
+}
+
+func (c LoadBalancerFieldChange) String() string {
+	switch c.Kind {
+	case LoadBalancerChangeAdd:
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Field, c.New)
+	case LoadBalancerChangeRemove:
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Field, c.Old)
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, c.Field, c.Old, c.New)
+	}
+}
+
+// LoadBalancerPlan is the set of changes needed to get from Current to Desired.
+type LoadBalancerPlan struct {
+	Action  LoadBalancerPlanAction
+	Current LoadBalancer
+	Desired LoadBalancer
+	Changes []LoadBalancerFieldChange
+}
+
+func (p LoadBalancerPlan) HasChanges() bool {
+	return p.Action != LoadBalancerPlanActionNone
+}
+
+func (p LoadBalancerPlan) String() string {
+	var sb strings.Builder
+	switch p.Action {
+	case LoadBalancerPlanActionCreate:
+		fmt.Fprintf(&sb, "create load balancer %q\n", p.Desired.Name)
+	case LoadBalancerPlanActionDelete:
+		fmt.Fprintf(&sb, "delete load balancer %q (%d)\n", p.Current.Name, p.Current.ID)
+	case LoadBalancerPlanActionUpdate:
+		fmt.Fprintf(&sb, "update load balancer %q (%d)\n", p.Current.Name, p.Current.ID)
+	default:
+		fmt.Fprintf(&sb, "no changes to load balancer %q (%d)\n", p.Current.Name, p.Current.ID)
+	}
+	for _, change := range p.Changes {
+		fmt.Fprintf(&sb, "  %s\n", change)
+	}
+	return sb.String()
+}
+
+// loadBalancerServerFields are populated by Rackcorp and ignored when planning.
+var loadBalancerServerFields = []string{
+	"ID", "CustomerID", "Hostname", "StdName", "Status", "DateCreated", "DateModified", "Version",
+	"MonthlyAllocationMB", "MonthlyUsageMB", "TrafficRemainingMB", "Certificates",
+}
+
+// PlanLoadBalancer computes the changes to get from current to desired. A current load balancer
+// without an ID is planned as a create. Fields populated by Rackcorp, such as DateModified,
+// MonthlyUsageMB and StdName, and the IDs of ACLs, backends and refresh patterns are ignored.
+func PlanLoadBalancer(current LoadBalancer, desired LoadBalancer) LoadBalancerPlan {
+	plan := LoadBalancerPlan{
+		Action:  LoadBalancerPlanActionUpdate,
+		Current: current,
+		Desired: desired,
+	}
+	if current.ID.IsZero() {
+		plan.Action = LoadBalancerPlanActionCreate
+		current = LoadBalancer{}
+	}
+
+	// the deprecated CheckMode is compared as part of HealthCheck
+	current.HealthCheck = current.healthCheck()
+	desired.HealthCheck = desired.healthCheck()
+	ignore := append(slices.Clone(loadBalancerServerFields),
+		"ACLs", "Aliases", "Backends", "CheckMode", "Ports", "RefreshPatterns", "Regions")
+	plan.Changes = diffFields("", current, desired, ignore)
+
+	plan.Changes = append(plan.Changes, diffSets("aliases", current.Aliases, desired.Aliases)...)
+	plan.Changes = append(plan.Changes, diffSets("ports", current.Ports, desired.Ports)...)
+	plan.Changes = append(plan.Changes, diffSets("regions", current.Regions, desired.Regions)...)
+	aclKey := func(acl LoadBalancerACL) string { return acl.dataString() }
+	plan.Changes = append(plan.Changes, diffKeyed("acls", current.ACLs, desired.ACLs, aclKey, aclKey, "ID")...)
+	// backends are matched by UUID, so a renamed backend is a change rather than a remove and add
+	backends := mergeLoadBalancerIdentity(current, desired).Backends
+	backendKey := func(backend LoadBalancerBackend) string {
+		if backend.UUID != "" {
+			return backend.UUID
+		}
+		return backend.Name
+	}
+	backendLabel := func(backend LoadBalancerBackend) string { return backend.Name }
+	plan.Changes = append(plan.Changes, diffKeyed("backends", current.Backends, backends, backendKey, backendLabel,
+		"UUID", "Created", "Modified")...)
+	patternKey := func(pattern LoadBalancerRefreshPattern) string { return pattern.RegularExpression }
+	plan.Changes = append(plan.Changes, diffKeyed("refreshpatterns", current.RefreshPatterns, desired.RefreshPatterns,
+		patternKey, patternKey, "ID")...)
+	if !slices.EqualFunc(current.RefreshPatterns, desired.RefreshPatterns, func(a, b LoadBalancerRefreshPattern) bool {
+		return a.RegularExpression == b.RegularExpression
+	}) && len(current.RefreshPatterns) > 0 && len(desired.RefreshPatterns) > 0 {
+		plan.Changes = append(plan.Changes, LoadBalancerFieldChange{
+			Kind:  LoadBalancerChangeModify,
+			Field: "refreshpatterns order",
+			Old:   formatRefreshPatternOrder(current.RefreshPatterns),
+			New:   formatRefreshPatternOrder(desired.RefreshPatterns),
+		})
+	}
+
+	if plan.Action == LoadBalancerPlanActionUpdate && len(plan.Changes) == 0 {
+		plan.Action = LoadBalancerPlanActionNone
+	}
+	return plan
+}
+
+// PlanLoadBalancerDeletion plans the deletion of current.
+func PlanLoadBalancerDeletion(current LoadBalancer) LoadBalancerPlan {
+	return LoadBalancerPlan{
+		Action:  LoadBalancerPlanActionDelete,
+		Current: current,
+	}
+}
+
+// Apply executes plan with Create, UpdateIfUnmodified or Delete. Updates keep the IDs of the
+// current ACLs, backends and refresh patterns so that unchanged entries are not recreated.
+func (lbc *loadBalancerClient) Apply(ctx context.Context, plan LoadBalancerPlan) (*LoadBalancer, error) {
+	switch plan.Action {
+	case LoadBalancerPlanActionNone:
+		return &plan.Current, nil
+	case LoadBalancerPlanActionCreate:
+		return lbc.Create(ctx, plan.Desired)
+	case LoadBalancerPlanActionDelete:
+		return nil, lbc.Delete(ctx, plan.Current.ID)
+	case LoadBalancerPlanActionUpdate:
+		return lbc.UpdateIfUnmodified(ctx, mergeLoadBalancerIdentity(plan.Current, plan.Desired))
+	}
+	return nil, fmt.Errorf("unknown load balancer plan action %q", plan.Action)
+}
+
+// mergeLoadBalancerIdentity returns desired with the server assigned identifiers of current, and the
+// fields populated by Rackcorp, such as CustomerID, taken from current where desired leaves them unset.
+func mergeLoadBalancerIdentity(current LoadBalancer, desired LoadBalancer) LoadBalancer {
+	merged := desired
+	merged.ID = current.ID
+	merged.Version = current.Version
+	merged.loaded = current.loaded
+
+	currentValue := reflect.ValueOf(current)
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	for _, name := range loadBalancerServerFields {
+		if field := mergedValue.FieldByName(name); field.IsZero() {
+			field.Set(currentValue.FieldByName(name))
+		}
+	}
+
+	merged.ACLs = slices.Clone(desired.ACLs)
+	for idx, acl := range merged.ACLs {
+		for _, existing := range current.ACLs {
+			if acl.ID == 0 && existing.dataString() == acl.dataString() {
+				merged.ACLs[idx].ID = existing.ID
+			}
+		}
+	}
+	merged.Backends = slices.Clone(desired.Backends)
+	for idx, backend := range merged.Backends {
+		for _, existing := range current.Backends {
+			if backend.UUID == "" && existing.Name == backend.Name {
+				merged.Backends[idx].UUID = existing.UUID
+			}
+		}
+	}
+	merged.RefreshPatterns = slices.Clone(desired.RefreshPatterns)
+	for idx, pattern := range merged.RefreshPatterns {
+		for _, existing := range current.RefreshPatterns {
+			if pattern.ID == 0 && existing.RegularExpression == pattern.RegularExpression {
+				merged.RefreshPatterns[idx].ID = existing.ID
+			}
+		}
+	}
+	return merged
+}
+
+func formatRefreshPatternOrder(patterns []LoadBalancerRefreshPattern) string {
+	regexes := make([]string, len(patterns))
+	for idx, pattern := range patterns {
+		regexes[idx] = pattern.RegularExpression
+	}
+	return fmt.Sprintf("%q", regexes)
+}
+
+// diffFields compares the exported fields of two structs of the same type, recursing into nested structs.
+func diffFields(prefix string, old any, new any, ignore []string) []LoadBalancerFieldChange {
+	var changes []LoadBalancerFieldChange
+	oldValue := reflect.ValueOf(old)
+	newValue := reflect.ValueOf(new)
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		if !field.IsExported() || slices.Contains(ignore, field.Name) {
+			continue
+		}
+		name := prefix + strings.ToLower(field.Name)
+		oldField := oldValue.Field(i).Interface()
+		newField := newValue.Field(i).Interface()
+		if field.Type.Kind() == reflect.Struct && field.Type.NumField() > 0 && field.Type.Field(0).IsExported() {
+			changes = append(changes, diffFields(name+".", oldField, newField, nil)...)
+			continue
+		}
+		if reflect.DeepEqual(oldField, newField) || isEmptySlice(oldField) && isEmptySlice(newField) {
+			continue
+		}
+		changes = append(changes, LoadBalancerFieldChange{
+			Kind:  LoadBalancerChangeModify,
+			Field: name,
+			Old:   formatPlanValue(oldField),
+			New:   formatPlanValue(newField),
+		})
+	}
+	return changes
+}
+
+// diffSets compares two lists ignoring order.
+func diffSets[T comparable](field string, old []T, new []T) []LoadBalancerFieldChange {
+	var changes []LoadBalancerFieldChange
+	for _, value := range old {
+		if !slices.Contains(new, value) {
+			changes = append(changes, LoadBalancerFieldChange{Kind: LoadBalancerChangeRemove, Field: field, Old: formatPlanValue(value)})
+		}
+	}
+	for _, value := range new {
+		if !slices.Contains(old, value) {
+			changes = append(changes, LoadBalancerFieldChange{Kind: LoadBalancerChangeAdd, Field: field, New: formatPlanValue(value)})
+		}
+	}
+	return changes
+}
+
+// diffKeyed compares two lists of structs matched by key, ignoring server assigned fields. Entries
+// are named by label in the changes.
+func diffKeyed[T any](field string, old []T, new []T, key func(T) string, label func(T) string, ignore ...string) []LoadBalancerFieldChange {
+	var changes []LoadBalancerFieldChange
+	oldByKey := map[string]T{}
+	for _, value := range old {
+		oldByKey[key(value)] = value
+	}
+	newKeys := map[string]bool{}
+	for _, value := range new {
+		k := key(value)
+		newKeys[k] = true
+		existing, ok := oldByKey[k]
+		if !ok {
+			changes = append(changes, LoadBalancerFieldChange{Kind: LoadBalancerChangeAdd, Field: fmt.Sprintf("%s[%s]", field, label(value)), New: formatPlanValue(value)})
+			continue
+		}
+		changes = append(changes, diffFields(fmt.Sprintf("%s[%s].", field, label(existing)), existing, value, ignore)...)
+	}
+	for _, value := range old {
+		if k := key(value); !newKeys[k] {
+			changes = append(changes, LoadBalancerFieldChange{Kind: LoadBalancerChangeRemove, Field: fmt.Sprintf("%s[%s]", field, label(value)), Old: formatPlanValue(value)})
+		}
+	}
+	return changes
+}
+
+func isEmptySlice(value any) bool {
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Slice && v.Len() == 0
+}
+
+func formatPlanValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%+v", value)
+}
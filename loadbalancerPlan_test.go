@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanLoadBalancer(t *testing.T) {
+	current := getTestLoadBalancer(t)
+
+	desired := clearServerPopulatedFields(current)
+	desired.ID = 0
+	desired.DateModified = time.Now()
+	desired.MonthlyUsageMB = 99999
+	desired.Backends = slices.Clone(current.Backends)
+	desired.Aliases = []string{"www.example.com", "cdn.example.com"}
+	desired.Backends[0].UUID = ""
+	desired.Backends[0].Weight = 10
+	desired.Backends = desired.Backends[:1]
+	desired.HealthCheck.Path = "/ready"
+
+	plan := PlanLoadBalancer(current, desired)
+	assert.Equal(t, LoadBalancerPlanActionUpdate, plan.Action, "Action")
+	assert.Equal(t, []LoadBalancerFieldChange{
+		{Kind: LoadBalancerChangeModify, Field: "healthcheck.path", Old: `"/healthz"`, New: `"/ready"`},
+		{Kind: LoadBalancerChangeRemove, Field: "aliases", Old: `"static.example.com"`},
+		{Kind: LoadBalancerChangeAdd, Field: "aliases", New: `"cdn.example.com"`},
+		{Kind: LoadBalancerChangeModify, Field: "backends[origin1].weight", Old: "100", New: "10"},
+		{Kind: LoadBalancerChangeRemove, Field: "backends[origin2]", Old: formatPlanValue(current.Backends[1])},
+	}, plan.Changes, "Changes")
+	assert.Contains(t, plan.String(), "update load balancer \"cdn-example\" (1234)\n  ~ healthcheck.path: \"/healthz\" -> \"/ready\"\n", "String")
+
+	plan = PlanLoadBalancer(current, clearServerPopulatedFields(current))
+	assert.Equal(t, LoadBalancerPlanActionNone, plan.Action, "unchanged Action")
+	assert.Empty(t, plan.Changes, "unchanged Changes")
+
+	desired = clearServerPopulatedFields(current)
+	desired.Backends[0].Name = "origin1-renamed"
+	plan = PlanLoadBalancer(current, desired)
+	assert.Equal(t, []LoadBalancerFieldChange{
+		{Kind: LoadBalancerChangeModify, Field: "backends[origin1].name", Old: `"origin1"`, New: `"origin1-renamed"`},
+	}, plan.Changes, "renamed backend is matched by UUID")
+
+	plan = PlanLoadBalancer(LoadBalancer{}, desired)
+	assert.Equal(t, LoadBalancerPlanActionCreate, plan.Action, "create Action")
+	assert.NotEmpty(t, plan.Changes, "create Changes")
+}
+
+func TestLoadBalancerApply(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	current := getTestLoadBalancer(t)
+
+	desired := clearServerPopulatedFields(current)
+	desired.ID = 0
+	desired.Backends = slices.Clone(current.Backends)
+	for idx := range desired.Backends {
+		desired.Backends[idx].UUID = ""
+	}
+	desired.Name = "cdn-example-renamed"
+
+	var req loadBalancerUpdateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(getTestDataString(t, "loadbalancer.get.responseBody.json"))
+
+	plan := PlanLoadBalancer(current, desired)
+	_, err := client.LoadBalancer().Apply(context.TODO(), plan)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "Apply error")
+
+	assert.Equal(t, LoadBalancerID(1234), req.Id, "Id")
+	assert.Equal(t, 7, req.Version, "Version")
+	assert.Equal(t, "cdn-example-renamed", req.Name, "Name")
+	require.Len(t, req.Backends, 2, "Backends")
+	assert.Equal(t, current.Backends[0].UUID, req.Backends[0].UUID, "Backends[0].UUID")
+}
+
+func TestLoadBalancerApplyLocalScope(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	current := getTestLoadBalancer(t)
+	current.Scope = LoadBalancerScopeLocal
+	current.ScopeNetworkID = 25
+
+	desired := clearServerPopulatedFields(current)
+	desired.Name = "cdn-example-renamed"
+
+	gock.New("https://api.rackcorp.net").
+		Get("/api/v2.9/networks/25").
+		Reply(200).
+		BodyString(`{"code":"OK","network":{"id":"25","customerId":"789"}}`)
+	var req loadBalancerUpdateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(getTestDataString(t, "loadbalancer.get.responseBody.json"))
+
+	plan := PlanLoadBalancer(current, desired)
+	_, err := client.LoadBalancer().Apply(context.TODO(), plan)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "Apply error")
+
+	assert.Equal(t, "cdn-example-renamed", req.Name, "Name")
+	assert.Equal(t, LoadBalancerScopeLocal, req.Scope, "Scope")
+}
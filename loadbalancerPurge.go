@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type LoadBalancerPurgeMode string
+
+const (
+	LoadBalancerPurgeModeURL    LoadBalancerPurgeMode = "URL"
+	LoadBalancerPurgeModePrefix LoadBalancerPurgeMode = "PREFIX"
+	LoadBalancerPurgeModeRegex  LoadBalancerPurgeMode = "REGEX"
+	LoadBalancerPurgeModeAll    LoadBalancerPurgeMode = "ALL"
+)
+
+// LoadBalancerPurge selects the cached content of a CDN load balancer to invalidate. Value is
+// an exact URL or path for LoadBalancerPurgeModeURL, a path prefix such as "/static/" for
+// LoadBalancerPurgeModePrefix, a regular expression for LoadBalancerPurgeModeRegex and empty for LoadBalancerPurgeModeAll.
+// It is the payload of TransactionTypePurge.
+type LoadBalancerPurge struct {
+	Mode  LoadBalancerPurgeMode `json:"mode"`
+	Value string                `json:"value,omitempty"`
+}
+
+// Validate checks the purge for errors that the transaction would fail on. A regular expression is not
+// compiled, as the CDN accepts PCRE constructs that Go does not; see Lint.
+func (p LoadBalancerPurge) Validate() error {
+	switch p.Mode {
+	case LoadBalancerPurgeModeURL:
+		if p.Value == "" {
+			return errors.New("purge URL is required")
+		}
+		if _, err := url.Parse(p.Value); err != nil {
+			return fmt.Errorf("purge URL %q is not valid: %w", p.Value, err)
+		}
+	case LoadBalancerPurgeModePrefix:
+		if !strings.HasPrefix(p.Value, "/") {
+			return fmt.Errorf("purge prefix %q must start with /", p.Value)
+		}
+	case LoadBalancerPurgeModeRegex:
+		if p.Value == "" {
+			return errors.New("purge regular expression is required")
+		}
+	case LoadBalancerPurgeModeAll:
+		if p.Value != "" {
+			return errors.New("purge value must be empty when purging everything")
+		}
+	default:
+		return fmt.Errorf("purge mode %q is not valid", p.Mode)
+	}
+	return nil
+}
+
+// Lint checks the purge more strictly than Validate, e.g. in CI: that a regular expression compiles as RE2.
+// Purges that the CDN accepts can fail Lint, so it is not checked by Purge.
+func (p LoadBalancerPurge) Lint() error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if p.Mode == LoadBalancerPurgeModeRegex {
+		if _, err := regexp.Compile(p.Value); err != nil {
+			return fmt.Errorf("purge regular expression %q is not valid RE2: %w", p.Value, err)
+		}
+	}
+	return nil
+}
+
+// Purge invalidates cached content of a CDN load balancer. The returned transaction can be waited on
+// with Client.TransactionWait until the content has been purged from every region.
+func (lbc *loadBalancerClient) Purge(ctx context.Context, id LoadBalancerID, purge LoadBalancerPurge) (*Transaction, error) {
+	if id.IsZero() {
+		return nil, errors.New("id parameter is required")
+	}
+	if err := purge.Validate(); err != nil {
+		return nil, err
+	}
+
+	transaction, err := lbc.c.TransactionCreateWithData(
+		ctx,
+		TransactionTypePurge,
+		TransactionObjectTypeLoadBalancer,
+		strconv.Itoa(int(id)),
+		true,
+		purge,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge load balancer Id '%d': %w", id, err)
+	}
+	return transaction, nil
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalancerPurge(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "LOADBALANCER", "objId": "1234", "type": "PURGE", "confirmation": true, "data": `{"mode":"PREFIX","value":"/static/"}`}).
+		Reply(200).
+		BodyString(getTestDataString(t, "rctransaction.create.responseBody.json"))
+
+	transaction, err := client.LoadBalancer().Purge(context.TODO(), 1234, LoadBalancerPurge{
+		Mode:  LoadBalancerPurgeModePrefix,
+		Value: "/static/",
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "Purge error")
+	assert.Equal(t, "141414", transaction.TransactionId, "TransactionId")
+}
+
+func TestLoadBalancerPurgeValidate(t *testing.T) {
+	assert.NoError(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeURL, Value: "https://www.example.com/static/site.css"}.Validate(), "URL")
+	assert.NoError(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModePrefix, Value: "/static/"}.Validate(), "prefix")
+	assert.NoError(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeRegex, Value: `\.css$`}.Validate(), "regex")
+	assert.NoError(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeAll}.Validate(), "all")
+
+	assert.Error(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeURL}.Validate(), "URL without value")
+	assert.Error(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModePrefix, Value: "static/"}.Validate(), "relative prefix")
+	assert.NoError(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeRegex, Value: `^/(?!api/)`}.Validate(), "PCRE regex")
+	assert.Error(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeRegex, Value: `^/(?!api/)`}.Lint(), "PCRE regex lint")
+	assert.Error(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeRegex, Value: "(css"}.Lint(), "invalid regex lint")
+	assert.NoError(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeRegex, Value: `\.css$`}.Lint(), "regex lint")
+	assert.Error(t, LoadBalancerPurge{Mode: LoadBalancerPurgeModeAll, Value: "/"}.Validate(), "all with value")
+	assert.Error(t, LoadBalancerPurge{Mode: "EVERYTHING"}.Validate(), "invalid mode")
+
+	_, err := getTestClient(t).LoadBalancer().Purge(context.TODO(), 1234, LoadBalancerPurge{})
+	assert.Error(t, err, "Purge with invalid purge")
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Validate checks the refresh pattern for errors that would make create or update fail. It does not
+// compile the regular expression, as the CDN accepts PCRE constructs that Go does not; see Lint.
+func (p LoadBalancerRefreshPattern) Validate() error {
+	var errs []error
+	if p.RegularExpression == "" {
+		errs = append(errs, errors.New("regular expression is required"))
+	}
+	if p.IPRestrictionDefaultPolicy != "" && !p.IPRestrictionDefaultPolicy.IsValid() {
+		errs = append(errs, fmt.Errorf("IP restriction default policy %q is not valid", p.IPRestrictionDefaultPolicy))
+	}
+	for idx, restriction := range p.IPRestrictions {
+		if err := restriction.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("IP restriction %d: %w", idx, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Lint checks the refresh pattern more strictly than Validate, e.g. in CI: that the regular expression
+// compiles as RE2, so that MatchRefreshPattern can test it, and that the redirect and TTL settings are
+// consistent. Patterns that the CDN accepts can fail Lint, so it is not checked on create or update.
+func (p LoadBalancerRefreshPattern) Lint() error {
+	errs := []error{p.Validate()}
+	if p.RegularExpression != "" {
+		if _, err := regexp.Compile(p.RegularExpression); err != nil {
+			errs = append(errs, fmt.Errorf("regular expression %q is not valid RE2: %w", p.RegularExpression, err))
+		}
+	}
+	switch p.RedirectCode {
+	case 0:
+		if p.RedirectURL != "" {
+			errs = append(errs, errors.New("redirect URL requires a redirect code"))
+		}
+	case 301, 302, 303, 307, 308:
+		if p.RedirectURL == "" && !p.RedirectForceHTTPS {
+			errs = append(errs, fmt.Errorf("redirect code %d requires a redirect URL or redirect force HTTPS", p.RedirectCode))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("redirect code %d is not a redirect", p.RedirectCode))
+	}
+	if p.MaxTTL != 0 && p.MinTTL > p.MaxTTL {
+		errs = append(errs, fmt.Errorf("min TTL %s is greater than max TTL %s", p.MinTTL, p.MaxTTL))
+	}
+	return errors.Join(errs...)
+}
+
+// LintRefreshPatterns runs Lint on each refresh pattern of the load balancer.
+func (lb LoadBalancer) LintRefreshPatterns() error {
+	var errs []error
+	for idx, pattern := range lb.RefreshPatterns {
+		if err := pattern.Lint(); err != nil {
+			errs = append(errs, fmt.Errorf("refresh pattern %d: %w", idx, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ErrRefreshPatternNotEvaluable is returned by AllowsRequest when the answer depends on a refresh pattern
+// that cannot be evaluated locally.
+var ErrRefreshPatternNotEvaluable = errors.New("refresh pattern cannot be evaluated locally")
+
+// LoadBalancerRefreshPatternMatch is the result of MatchRefreshPattern for a single URL path.
+type LoadBalancerRefreshPatternMatch struct {
+	Path string
+	// Index is the index in LoadBalancer.RefreshPatterns of the first matching pattern, or -1 if none match.
+	Index   int
+	Pattern LoadBalancerRefreshPattern
+	// Shadowed is the indexes of later patterns that also match the path but are never applied to it.
+	Shadowed []int
+	// Unevaluated is the indexes of patterns that cannot be evaluated locally, because their regular
+	// expression is not RE2, e.g. a PCRE lookahead that the CDN accepts.
+	Unevaluated []int
+}
+
+func (m LoadBalancerRefreshPatternMatch) Matched() bool {
+	return m.Index >= 0
+}
+
+// Certain reports whether the match is the one the CDN applies, i.e. no pattern before it could not
+// be evaluated. A path that matches no pattern is only certain if every pattern was evaluated.
+func (m LoadBalancerRefreshPatternMatch) Certain() bool {
+	return len(m.Unevaluated) == 0 || (m.Matched() && m.Unevaluated[0] > m.Index)
+}
+
+func (m LoadBalancerRefreshPatternMatch) MinTTL() time.Duration {
+	return m.Pattern.MinTTL
+}
+
+func (m LoadBalancerRefreshPatternMatch) CacheTime() time.Duration {
+	return m.Pattern.CacheTime
+}
+
+func (m LoadBalancerRefreshPatternMatch) MaxTTL() time.Duration {
+	return m.Pattern.MaxTTL
+}
+
+func (m LoadBalancerRefreshPatternMatch) RedirectCode() int {
+	return m.Pattern.RedirectCode
+}
+
+func (m LoadBalancerRefreshPatternMatch) IPRestrictions() []LoadBalancerIPRestriction {
+	return m.Pattern.IPRestrictions
+}
+
+// AllowsIP reports whether clientIP may request the path. Paths without a matching pattern are allowed.
+func (m LoadBalancerRefreshPatternMatch) AllowsIP(clientIP netip.Addr) bool {
+	if !m.Matched() {
+		return true
+	}
+	return m.Pattern.AllowsIP(clientIP)
+}
+
+// AllowsIP evaluates the IP restrictions of the pattern for clientIP. The first restriction
+// containing clientIP decides, otherwise IPRestrictionDefaultPolicy applies. An empty default policy allows.
+func (p LoadBalancerRefreshPattern) AllowsIP(clientIP netip.Addr) bool {
+	clientIP = clientIP.Unmap()
+	for _, restriction := range p.IPRestrictions {
+		if restriction.IP.Contains(clientIP) {
+			return restriction.Action == LoadBalancerACLActionAllow
+		}
+	}
+	return p.IPRestrictionDefaultPolicy != LoadBalancerACLActionDeny
+}
+
+// AllowsRequest reports whether clientIP may request path, using the first refresh pattern matching path.
+// ErrRefreshPatternNotEvaluable is returned if a pattern that cannot be evaluated locally could apply instead.
+func (lb LoadBalancer) AllowsRequest(path string, clientIP netip.Addr) (bool, error) {
+	match := lb.MatchRefreshPattern(path)
+	if !match.Certain() {
+		idx := match.Unevaluated[0]
+		return false, fmt.Errorf("%s: refresh pattern %d %q: %w", path, idx, lb.RefreshPatterns[idx].RegularExpression, ErrRefreshPatternNotEvaluable)
+	}
+	return match.AllowsIP(clientIP), nil
+}
+
+func (m LoadBalancerRefreshPatternMatch) String() string {
+	if !m.Matched() {
+		if len(m.Unevaluated) > 0 {
+			return fmt.Sprintf("%s: no refresh pattern matches unevaluated=%v", m.Path, m.Unevaluated)
+		}
+		return fmt.Sprintf("%s: no refresh pattern matches", m.Path)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: refresh pattern %d %q", m.Path, m.Index, m.Pattern.RegularExpression)
+	if m.Pattern.RedirectCode != 0 {
+		fmt.Fprintf(&sb, " redirect=%d", m.Pattern.RedirectCode)
+		if m.Pattern.RedirectURL != "" {
+			fmt.Fprintf(&sb, " %s", m.Pattern.RedirectURL)
+		}
+	} else {
+		fmt.Fprintf(&sb, " minttl=%s cachetime=%s maxttl=%s", m.Pattern.MinTTL, m.Pattern.CacheTime, m.Pattern.MaxTTL)
+	}
+	if len(m.Pattern.IPRestrictions) > 0 {
+		fmt.Fprintf(&sb, " iprestrictions=%d", len(m.Pattern.IPRestrictions))
+	}
+	if len(m.Shadowed) > 0 {
+		fmt.Fprintf(&sb, " shadows=%v", m.Shadowed)
+	}
+	if len(m.Unevaluated) > 0 {
+		fmt.Fprintf(&sb, " unevaluated=%v", m.Unevaluated)
+	}
+	return sb.String()
+}
+
+// MatchRefreshPattern reports which refresh pattern applies to the URL path. Patterns are tried in order
+// and the first match wins, as on the CDN. Patterns that are not valid RE2 cannot be evaluated locally and
+// are reported in Unevaluated rather than failing the match; see Certain.
+func (lb LoadBalancer) MatchRefreshPattern(path string) LoadBalancerRefreshPatternMatch {
+	match := LoadBalancerRefreshPatternMatch{
+		Path:  path,
+		Index: -1,
+	}
+	for idx, pattern := range lb.RefreshPatterns {
+		re, err := regexp.Compile(pattern.RegularExpression)
+		if err != nil {
+			match.Unevaluated = append(match.Unevaluated, idx)
+			continue
+		}
+		if !re.MatchString(path) {
+			continue
+		}
+		if match.Matched() {
+			match.Shadowed = append(match.Shadowed, idx)
+			continue
+		}
+		match.Index = idx
+		match.Pattern = pattern
+	}
+	return match
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalancerRefreshPatternValidate(t *testing.T) {
+	assert.NoError(t, LoadBalancerRefreshPattern{RegularExpression: `\.(css|js)$`}.Validate(), "valid")
+	assert.Error(t, LoadBalancerRefreshPattern{}.Validate(), "missing regular expression")
+
+	// the CDN accepts PCRE, so patterns Go can't compile and unusual redirect codes must not block updates
+	assert.NoError(t, LoadBalancerRefreshPattern{RegularExpression: `^/(?!api/)`}.Validate(), "PCRE lookahead")
+	assert.NoError(t, LoadBalancerRefreshPattern{RegularExpression: "^/", RedirectCode: 200, RedirectURL: "https://example.com/"}.Validate(), "unusual redirect code")
+
+	lb := getTestLoadBalancer(t)
+	lb.RefreshPatterns[1].RegularExpression = `^/(admin)/\1`
+	assert.NoError(t, lb.Validate(), "LoadBalancer.Validate with a PCRE backreference")
+	assert.Error(t, lb.LintRefreshPatterns(), "LoadBalancer.LintRefreshPatterns with a PCRE backreference")
+}
+
+func TestLoadBalancerRefreshPatternLint(t *testing.T) {
+	assert.NoError(t, LoadBalancerRefreshPattern{RegularExpression: `\.(css|js)$`}.Lint(), "valid")
+	assert.NoError(t, LoadBalancerRefreshPattern{RegularExpression: "^/old/", RedirectCode: 301, RedirectURL: "https://example.com/new/"}.Lint(), "valid redirect")
+	assert.Error(t, LoadBalancerRefreshPattern{}.Lint(), "missing regular expression")
+	assert.Error(t, LoadBalancerRefreshPattern{RegularExpression: "^/static/(css"}.Lint(), "invalid regular expression")
+	assert.Error(t, LoadBalancerRefreshPattern{RegularExpression: `^/(?!api/)`}.Lint(), "PCRE lookahead")
+	assert.Error(t, LoadBalancerRefreshPattern{RegularExpression: "^/", RedirectCode: 200, RedirectURL: "https://example.com/"}.Lint(), "invalid redirect code")
+	assert.Error(t, LoadBalancerRefreshPattern{RegularExpression: "^/", RedirectURL: "https://example.com/"}.Lint(), "redirect URL without code")
+	assert.Error(t, LoadBalancerRefreshPattern{RegularExpression: "^/", MinTTL: time.Hour, MaxTTL: time.Minute}.Lint(), "min TTL above max TTL")
+}
+
+func TestLoadBalancerMatchRefreshPattern(t *testing.T) {
+	lb := getTestLoadBalancer(t)
+	lb.RefreshPatterns = append(lb.RefreshPatterns, LoadBalancerRefreshPattern{RegularExpression: `\.css$`})
+
+	match := lb.MatchRefreshPattern("/static/site.css")
+	assert.True(t, match.Matched(), "Matched")
+	assert.Equal(t, 0, match.Index, "Index")
+	assert.Equal(t, []int{2}, match.Shadowed, "Shadowed")
+	assert.Equal(t, time.Hour, match.MinTTL(), "MinTTL")
+	assert.Equal(t, 24*time.Hour, match.CacheTime(), "CacheTime")
+	assert.Equal(t, 7*24*time.Hour, match.MaxTTL(), "MaxTTL")
+	assert.Equal(t, `/static/site.css: refresh pattern 0 "^/static/" minttl=1h0m0s cachetime=24h0m0s maxttl=168h0m0s shadows=[2]`, match.String(), "String")
+
+	match = lb.MatchRefreshPattern("/admin/login")
+	assert.Equal(t, 1, match.Index, "Index")
+	assert.Len(t, match.IPRestrictions(), 1, "IPRestrictions")
+	assert.Equal(t, 0, match.RedirectCode(), "RedirectCode")
+
+	match = lb.MatchRefreshPattern("/index.html")
+	assert.False(t, match.Matched(), "Matched")
+	assert.True(t, match.Certain(), "Certain")
+	assert.Equal(t, "/index.html: no refresh pattern matches", match.String(), "String")
+}
+
+func TestLoadBalancerMatchRefreshPatternUnevaluated(t *testing.T) {
+	lb := getTestLoadBalancer(t)
+	lb.RefreshPatterns = append(lb.RefreshPatterns, LoadBalancerRefreshPattern{RegularExpression: `^/(?!api/)`})
+
+	// the PCRE lookahead comes after the matching pattern, so it cannot change the result
+	match := lb.MatchRefreshPattern("/static/site.css")
+	assert.Equal(t, 0, match.Index, "Index")
+	assert.Equal(t, []int{2}, match.Unevaluated, "Unevaluated")
+	assert.True(t, match.Certain(), "Certain")
+	assert.Equal(t, `/static/site.css: refresh pattern 0 "^/static/" minttl=1h0m0s cachetime=24h0m0s maxttl=168h0m0s unevaluated=[2]`, match.String(), "String")
+
+	// the lookahead may match a path no other pattern matches
+	match = lb.MatchRefreshPattern("/index.html")
+	assert.False(t, match.Matched(), "Matched")
+	assert.False(t, match.Certain(), "Certain")
+	assert.Equal(t, "/index.html: no refresh pattern matches unevaluated=[2]", match.String(), "String")
+	_, err := lb.AllowsRequest("/index.html", netip.MustParseAddr("203.0.113.1"))
+	assert.True(t, errors.Is(err, ErrRefreshPatternNotEvaluable), "AllowsRequest error should be ErrRefreshPatternNotEvaluable: %v", err)
+
+	// the lookahead before the /admin/ pattern could apply instead of it
+	lb.RefreshPatterns[0].RegularExpression = `^/(?!static/)`
+	match = lb.MatchRefreshPattern("/admin/login")
+	assert.Equal(t, 1, match.Index, "Index")
+	assert.Equal(t, []int{0, 2}, match.Unevaluated, "Unevaluated")
+	assert.False(t, match.Certain(), "Certain")
+	_, err = lb.AllowsRequest("/admin/login", netip.MustParseAddr("198.51.100.7"))
+	assert.True(t, errors.Is(err, ErrRefreshPatternNotEvaluable), "AllowsRequest error should be ErrRefreshPatternNotEvaluable: %v", err)
+}
+
+func TestLoadBalancerRefreshPatternIPRestrictions(t *testing.T) {
+	lb := getTestLoadBalancer(t)
+	admin := lb.RefreshPatterns[1]
+	assert.Equal(t, LoadBalancerACLActionDeny, admin.IPRestrictionDefaultPolicy, "IPRestrictionDefaultPolicy")
+	assert.Equal(t, []LoadBalancerIPRestriction{
+		{IP: netip.MustParsePrefix("198.51.100.0/24"), Action: LoadBalancerACLActionAllow},
+	}, admin.IPRestrictions, "IPRestrictions")
+
+	assert.True(t, admin.AllowsIP(netip.MustParseAddr("198.51.100.7")), "allowed by restriction")
+	assert.True(t, admin.AllowsIP(netip.MustParseAddr("::ffff:198.51.100.7")), "allowed by restriction as IPv4-mapped IPv6")
+	assert.False(t, admin.AllowsIP(netip.MustParseAddr("203.0.113.1")), "denied by default policy")
+
+	allowed, err := lb.AllowsRequest("/admin/login", netip.MustParseAddr("203.0.113.1"))
+	require.NoError(t, err, "AllowsRequest error")
+	assert.False(t, allowed, "/admin/ denied")
+	allowed, err = lb.AllowsRequest("/index.html", netip.MustParseAddr("203.0.113.1"))
+	require.NoError(t, err, "AllowsRequest error")
+	assert.True(t, allowed, "unmatched path allowed")
+
+	sent := convertLoadBalancerRefreshPattern(admin)
+	assert.Equal(t, []loadBalancerIPRestriction{{IP: "198.51.100.0/24", Action: LoadBalancerACLActionAllow}}, sent.IPRestrictions, "converted IPRestrictions")
+
+	admin.IPRestrictions = append(admin.IPRestrictions, LoadBalancerIPRestriction{Action: "MAYBE"})
+	admin.IPRestrictionDefaultPolicy = "BLOCK"
+	assert.Error(t, admin.Validate(), "invalid IP restriction and default policy")
+}
+
+func TestLoadBalancerIPRestrictionUnparseable(t *testing.T) {
+	var e existingLoadBalancer
+	err := json.Unmarshal([]byte(`{"id":"1234","refreshpatterns":[{"id":"7","regularexpression":"^/admin/","iprestrictiondefaultpolicy":"DENY","iprestrictions":[{"ip":"198.51.100.*","action":"ALLOW"},{"ip":"203.0.113.0/24","action":"ALLOW"}]}]}`), &e)
+	require.NoError(t, err, "json.Unmarshal")
+
+	lb := e.ToLoadBalancer()
+	require.Len(t, lb.RefreshPatterns, 1, "RefreshPatterns")
+	admin := lb.RefreshPatterns[0]
+	require.Len(t, admin.IPRestrictions, 2, "IPRestrictions")
+	assert.False(t, admin.IPRestrictions[0].IP.IsValid(), "IPRestrictions[0].IP")
+	assert.Equal(t, "198.51.100.*", admin.IPRestrictions[0].RawIP, "IPRestrictions[0].RawIP")
+	assert.NoError(t, admin.Validate(), "Validate with an unparseable IP restriction")
+
+	assert.False(t, admin.AllowsIP(netip.MustParseAddr("198.51.100.7")), "unparseable restriction never matches")
+	assert.True(t, admin.AllowsIP(netip.MustParseAddr("203.0.113.7")), "parsed restriction still applies")
+
+	sent := convertLoadBalancerRefreshPattern(admin)
+	assert.Equal(t, []loadBalancerIPRestriction{
+		{IP: "198.51.100.*", Action: LoadBalancerACLActionAllow},
+		{IP: "203.0.113.0/24", Action: LoadBalancerACLActionAllow},
+	}, sent.IPRestrictions, "unparseable IP sent back unchanged")
+}
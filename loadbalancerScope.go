@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rackcorpcloud/rackcorp-api-go/apiv2"
+)
+
+// validateScopeNetwork checks that the network of a local scope load balancer exists and belongs to its customer.
+// The load balancer must have a CustomerID, as otherwise the ownership of the network cannot be checked.
+func (lbc *loadBalancerClient) validateScopeNetwork(ctx context.Context, lb LoadBalancer) error {
+	if lb.Scope != LoadBalancerScopeLocal {
+		return nil
+	}
+	if lb.CustomerID == 0 {
+		return fmt.Errorf("local scope requires a customer ID to check scope network %d", lb.ScopeNetworkID)
+	}
+	network, err := lbc.c.Network().Get(ctx, lb.ScopeNetworkID)
+	if err != nil {
+		return fmt.Errorf("scope network %d: %w", lb.ScopeNetworkID, err)
+	}
+	if network.CustomerID == 0 {
+		return fmt.Errorf("scope network %d is not assigned to a customer, so it cannot be used by customer %d", network.ID, lb.CustomerID)
+	}
+	if network.CustomerID != lb.CustomerID {
+		return fmt.Errorf("scope network %d belongs to customer %d, not customer %d", network.ID, network.CustomerID, lb.CustomerID)
+	}
+	return nil
+}
+
+func (lbc *loadBalancerClient) CreateLocalForDevice(ctx context.Context, deviceID apiv2.DeviceID, lb LoadBalancer) (*LoadBalancer, error) {
+	device, err := lbc.c.DeviceGet(ctx, int(deviceID))
+	if err != nil {
+		return nil, err
+	}
+
+	networkID := deviceScopeNetworkID(device.IPs)
+	if networkID == 0 {
+		return nil, fmt.Errorf("device %d has no IP on a network", deviceID)
+	}
+
+	lb.Scope = LoadBalancerScopeLocal
+	lb.ScopeNetworkID = networkID
+	if lb.CustomerID == 0 {
+		lb.CustomerID = CustomerID(device.CustomerId)
+	}
+	return lbc.Create(ctx, lb)
+}
+
+// deviceScopeNetworkID returns the network of the first private IP, otherwise of the primary IP.
+func deviceScopeNetworkID(ips []DeviceIP) NetworkID {
+	for _, ip := range ips {
+		if ip.NetworkID != 0 && ip.Address.IsPrivate() {
+			return ip.NetworkID
+		}
+	}
+	for _, ip := range ips {
+		if ip.NetworkID != 0 && ip.Primary {
+			return ip.NetworkID
+		}
+	}
+	return 0
+}
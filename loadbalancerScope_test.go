@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalancerCreateLocalForDevice(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Get("/api/v2.9/devices/5075").
+		Reply(200).
+		BodyString(getTestDataString(t, "device.get.responseBody.json"))
+	gock.New("https://api.rackcorp.net").
+		Get("/api/v2.9/networks/25").
+		Reply(200).
+		BodyString(`{"code":"OK","network":{"id":"25","customerId":"789","name":"customer VLAN","vlanId":"100"}}`)
+	var req loadBalancerCreateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.create", &req)).
+		Reply(200).
+		BodyString(getTestDataString(t, "loadbalancer.get.responseBody.json"))
+
+	_, err := client.LoadBalancer().CreateLocalForDevice(context.TODO(), 5075, LoadBalancer{
+		Name:           "internal",
+		Type:           LoadBalancerTypeTCP,
+		Ports:          []int{5432},
+		ScopeInstances: 2,
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "CreateLocalForDevice error")
+
+	assert.Equal(t, LoadBalancerScopeLocal, req.Scope, "Scope")
+	assert.Equal(t, NetworkID(25), req.ScopeNetworkID, "ScopeNetworkID")
+	assert.Equal(t, 2, req.ScopeInstances, "ScopeInstances")
+	assert.Equal(t, CustomerID(789), req.CustomerID, "CustomerID")
+}
+
+func TestLoadBalancerCreateLocalOtherCustomerNetwork(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Get("/api/v2.9/networks/25").
+		Reply(200).
+		BodyString(`{"code":"OK","network":{"id":"25","customerId":"1000"}}`)
+
+	_, err := client.LoadBalancer().Create(context.TODO(), LoadBalancer{
+		Name:           "internal",
+		Type:           LoadBalancerTypeTCP,
+		CustomerID:     789,
+		Scope:          LoadBalancerScopeLocal,
+		ScopeNetworkID: 25,
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.EqualError(t, err, "scope network 25 belongs to customer 1000, not customer 789", "Create error")
+}
+
+func TestLoadBalancerScopeValidate(t *testing.T) {
+	lb := LoadBalancer{Scope: LoadBalancerScopeLocal, ScopeNetworkID: 25, ScopeInstances: 2}
+	assert.NoError(t, lb.Validate(), "local")
+
+	lb.ScopeNetworkID = 0
+	assert.Error(t, lb.Validate(), "local without network")
+
+	lb = LoadBalancer{Scope: LoadBalancerScopeLocal, ScopeNetworkID: 25, ScopeInstances: -1}
+	assert.Error(t, lb.Validate(), "local with negative instances")
+
+	lb = LoadBalancer{Scope: LoadBalancerScopeLocal, ScopeNetworkID: 25, ScopeInstances: LoadBalancerMaxScopeInstances + 1}
+	assert.Error(t, lb.Validate(), "local with too many instances")
+
+	lb = LoadBalancer{Scope: LoadBalancerScopeGlobal, ScopeNetworkID: 25, ScopeInstances: 1}
+	assert.NoError(t, lb.Validate(), "global with server default network and instances")
+}
+
+func TestLoadBalancerCreateLocalWithoutCustomer(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	_, err := client.LoadBalancer().Create(context.TODO(), LoadBalancer{
+		Name:           "internal",
+		Type:           LoadBalancerTypeTCP,
+		Scope:          LoadBalancerScopeLocal,
+		ScopeNetworkID: 25,
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.EqualError(t, err, "local scope requires a customer ID to check scope network 25", "Create error")
+}
+
+func TestLoadBalancerCreateLocalUnownedNetwork(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Get("/api/v2.9/networks/25").
+		Reply(200).
+		BodyString(`{"code":"OK","network":{"id":"25"}}`)
+
+	_, err := client.LoadBalancer().Create(context.TODO(), LoadBalancer{
+		Name:           "internal",
+		Type:           LoadBalancerTypeTCP,
+		CustomerID:     789,
+		Scope:          LoadBalancerScopeLocal,
+		ScopeNetworkID: 25,
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.EqualError(t, err, "scope network 25 is not assigned to a customer, so it cannot be used by customer 789", "Create error")
+}
+
+func TestLoadBalancerUpdateLocalScopeNetworkChanged(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := strings.Replace(getTestDataString(t, "loadbalancer.get.responseBody.json"),
+		`"scope": "global",
+        "scope_networkid": 0,`, `"scope": "local",
+        "scope_networkid": 25,`, 1)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", nil)).
+		Times(2).
+		Reply(200).
+		BodyString(responseBody)
+
+	lb, err := client.LoadBalancer().Get(context.TODO(), 1234)
+	require.NoError(t, err, "Get error")
+	require.Equal(t, NetworkID(25), lb.ScopeNetworkID, "ScopeNetworkID")
+
+	lb.Name = "renamed"
+	_, err = client.LoadBalancer().Update(context.TODO(), *lb)
+	require.NoError(t, err, "Update error without a network change")
+
+	gock.New("https://api.rackcorp.net").
+		Get("/api/v2.9/networks/31").
+		Reply(200).
+		BodyString(`{"code":"OK","network":{"id":"31","customerId":"789"}}`)
+	lb.ScopeNetworkID = 31
+	_, err = client.LoadBalancer().Update(context.TODO(), *lb)
+	require.NoError(t, err, "Update error with a network change")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+}
+
+func TestDeviceScopeNetworkID(t *testing.T) {
+	ips := []DeviceIP{
+		{Address: netip.MustParseAddr("192.0.2.10"), Primary: true, NetworkID: 25},
+		{Address: netip.MustParseAddr("10.1.2.3"), NetworkID: 31},
+	}
+	assert.Equal(t, NetworkID(31), deviceScopeNetworkID(ips), "private network")
+	assert.Equal(t, NetworkID(25), deviceScopeNetworkID(ips[:1]), "primary network")
+	assert.Equal(t, NetworkID(0), deviceScopeNetworkID(nil), "no network")
+}
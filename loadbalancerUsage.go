@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// loadBalancerUsagePageSize is the result window used by UsageReport to page through loadbalancer.getall.
+const loadBalancerUsagePageSize = 100
+
+// loadBalancerUsageMaxPages bounds the pages read by UsageReport, in case the API ignores resStart.
+const loadBalancerUsageMaxPages = 1000
+
+// LoadBalancerUsage is the monthly traffic usage of a single load balancer.
+// PercentUsed and ProjectedPercent are zero when the load balancer has no allocation.
+type LoadBalancerUsage struct {
+	ID                  LoadBalancerID
+	Name                string
+	CustomerID          CustomerID
+	MonthlyAllocationMB int
+	MonthlyUsageMB      int
+	TrafficRemainingMB  int
+	PercentUsed         float64
+	// ProjectedUsageMB extrapolates MonthlyUsageMB to the end of the month at the average rate since the start
+	// of the month, or since DateCreated for a load balancer created this month. It is MonthlyUsageMB for a
+	// load balancer that is not active, as it does not serve further traffic.
+	ProjectedUsageMB int
+	ProjectedPercent float64
+	// PredictedOverage is set when ProjectedUsageMB exceeds MonthlyAllocationMB.
+	PredictedOverage   bool
+	ProjectedOverageMB int
+}
+
+// LoadBalancerUsageReport is the traffic usage of a set of load balancers at a point in time.
+type LoadBalancerUsageReport struct {
+	GeneratedAt time.Time
+	MonthStart  time.Time
+	MonthEnd    time.Time
+	Usage       []LoadBalancerUsage
+
+	TotalAllocationMB int
+	TotalUsageMB      int
+	TotalProjectedMB  int
+}
+
+// PredictedOverages returns the load balancers predicted to exceed their allocation this month.
+func (r LoadBalancerUsageReport) PredictedOverages() []LoadBalancerUsage {
+	var overages []LoadBalancerUsage
+	for _, usage := range r.Usage {
+		if usage.PredictedOverage {
+			overages = append(overages, usage)
+		}
+	}
+	return overages
+}
+
+// NewLoadBalancerUsageReport computes the usage of lbs as at now. The month is the calendar month of now
+// in now's location. Usage is assumed to accrue from the start of the month, or from DateCreated for load
+// balancers created this month, and is only projected for active load balancers; see LoadBalancerUsage.
+func NewLoadBalancerUsageReport(lbs []LoadBalancer, now time.Time) LoadBalancerUsageReport {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	report := LoadBalancerUsageReport{
+		GeneratedAt: now,
+		MonthStart:  monthStart,
+		MonthEnd:    monthStart.AddDate(0, 1, 0),
+		Usage:       make([]LoadBalancerUsage, len(lbs)),
+	}
+
+	for idx, lb := range lbs {
+		usage := LoadBalancerUsage{
+			ID:                  lb.ID,
+			Name:                lb.Name,
+			CustomerID:          lb.CustomerID,
+			MonthlyAllocationMB: lb.MonthlyAllocationMB,
+			MonthlyUsageMB:      lb.MonthlyUsageMB,
+			TrafficRemainingMB:  lb.TrafficRemainingMB,
+			ProjectedUsageMB:    lb.MonthlyUsageMB,
+		}
+
+		start := monthStart
+		if lb.DateCreated.After(start) {
+			start = lb.DateCreated
+		}
+		active := lb.Status == "" || lb.Status == LoadBalancerStatusActive
+		if elapsed := now.Sub(start); active && elapsed > 0 && now.Before(report.MonthEnd) {
+			remaining := report.MonthEnd.Sub(start)
+			usage.ProjectedUsageMB = int(float64(lb.MonthlyUsageMB) * remaining.Seconds() / elapsed.Seconds())
+		}
+
+		if lb.MonthlyAllocationMB > 0 {
+			usage.PercentUsed = 100 * float64(lb.MonthlyUsageMB) / float64(lb.MonthlyAllocationMB)
+			usage.ProjectedPercent = 100 * float64(usage.ProjectedUsageMB) / float64(lb.MonthlyAllocationMB)
+			usage.PredictedOverage = usage.ProjectedUsageMB > lb.MonthlyAllocationMB
+			if usage.PredictedOverage {
+				usage.ProjectedOverageMB = usage.ProjectedUsageMB - lb.MonthlyAllocationMB
+			}
+		}
+
+		report.Usage[idx] = usage
+		report.TotalAllocationMB += usage.MonthlyAllocationMB
+		report.TotalUsageMB += usage.MonthlyUsageMB
+		report.TotalProjectedMB += usage.ProjectedUsageMB
+	}
+
+	return report
+}
+
+// UsageReport gets every load balancer matching filter, e.g. by CustomerID, and reports their usage as at now.
+// The ResultStart and ResultWindow of filter are ignored as all pages are read. An error is returned if a
+// page repeats load balancers already read, as the API then ignores resStart and paging would not end.
+func (lbc *loadBalancerClient) UsageReport(ctx context.Context, filter LoadBalancerFilter) (*LoadBalancerUsageReport, error) {
+	var lbs []LoadBalancer
+	seen := make(map[LoadBalancerID]bool)
+	filter.ResultWindow = loadBalancerUsagePageSize
+	for pageIdx := 0; ; pageIdx++ {
+		if pageIdx == loadBalancerUsageMaxPages {
+			return nil, fmt.Errorf("load balancer usage report exceeded %d pages of %d", loadBalancerUsageMaxPages, loadBalancerUsagePageSize)
+		}
+		filter.ResultStart = pageIdx * loadBalancerUsagePageSize
+		page, err := lbc.GetAll(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, lb := range page {
+			if seen[lb.ID] {
+				return nil, fmt.Errorf("load balancer usage report page at %d repeats load balancer %d", filter.ResultStart, lb.ID)
+			}
+			seen[lb.ID] = true
+		}
+		lbs = append(lbs, page...)
+		if len(page) < loadBalancerUsagePageSize {
+			break
+		}
+	}
+
+	report := NewLoadBalancerUsageReport(lbs, time.Now())
+	return &report, nil
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLoadBalancerUsageReport(t *testing.T) {
+	// 10 days into a 30 day month
+	now := time.Date(2025, time.June, 11, 0, 0, 0, 0, time.UTC)
+
+	report := NewLoadBalancerUsageReport([]LoadBalancer{
+		{ID: 1, Name: "on-track", MonthlyAllocationMB: 3000, MonthlyUsageMB: 500, TrafficRemainingMB: 2500},
+		{ID: 2, Name: "overage", MonthlyAllocationMB: 3000, MonthlyUsageMB: 1500, TrafficRemainingMB: 1500},
+		{ID: 3, Name: "new", MonthlyAllocationMB: 1000, MonthlyUsageMB: 100, DateCreated: time.Date(2025, time.June, 10, 0, 0, 0, 0, time.UTC), DateModified: time.Date(2025, time.June, 10, 12, 0, 0, 0, time.UTC)},
+		{ID: 4, Name: "unallocated", MonthlyUsageMB: 100, DateModified: time.Date(2025, time.June, 10, 0, 0, 0, 0, time.UTC)},
+		{ID: 5, Name: "deleted", Status: LoadBalancerStatusDeleted, MonthlyAllocationMB: 1000, MonthlyUsageMB: 900},
+	}, now)
+
+	assert.Equal(t, time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC), report.MonthStart, "MonthStart")
+	assert.Equal(t, time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC), report.MonthEnd, "MonthEnd")
+	require.Len(t, report.Usage, 5, "Usage")
+
+	assert.InDelta(t, 16.67, report.Usage[0].PercentUsed, 0.01, "on-track PercentUsed")
+	assert.Equal(t, 1500, report.Usage[0].ProjectedUsageMB, "on-track ProjectedUsageMB")
+	assert.False(t, report.Usage[0].PredictedOverage, "on-track PredictedOverage")
+
+	assert.Equal(t, 4500, report.Usage[1].ProjectedUsageMB, "overage ProjectedUsageMB")
+	assert.InDelta(t, 150, report.Usage[1].ProjectedPercent, 0.01, "overage ProjectedPercent")
+	assert.True(t, report.Usage[1].PredictedOverage, "overage PredictedOverage")
+	assert.Equal(t, 1500, report.Usage[1].ProjectedOverageMB, "overage ProjectedOverageMB")
+
+	assert.Equal(t, 2100, report.Usage[2].ProjectedUsageMB, "new ProjectedUsageMB is projected from DateCreated")
+	assert.True(t, report.Usage[2].PredictedOverage, "new PredictedOverage")
+
+	assert.Equal(t, 300, report.Usage[3].ProjectedUsageMB, "unallocated ProjectedUsageMB is projected from the start of the month, not DateModified")
+	assert.Zero(t, report.Usage[3].PercentUsed, "unallocated PercentUsed")
+	assert.False(t, report.Usage[3].PredictedOverage, "unallocated PredictedOverage")
+
+	assert.Equal(t, 900, report.Usage[4].ProjectedUsageMB, "deleted ProjectedUsageMB is not projected")
+	assert.False(t, report.Usage[4].PredictedOverage, "deleted PredictedOverage")
+
+	assert.Equal(t, 8000, report.TotalAllocationMB, "TotalAllocationMB")
+	assert.Equal(t, 3100, report.TotalUsageMB, "TotalUsageMB")
+
+	overages := report.PredictedOverages()
+	require.Len(t, overages, 2, "PredictedOverages")
+	assert.Equal(t, LoadBalancerID(2), overages[0].ID, "PredictedOverages[0]")
+	assert.Equal(t, LoadBalancerID(3), overages[1].ID, "PredictedOverages[1]")
+}
+
+func TestLoadBalancerUsageReport(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	lbs := make([]string, loadBalancerUsagePageSize)
+	for idx := range lbs {
+		lbs[idx] = fmt.Sprintf(`{"id":"%d","customerid":"42","monthlyallocationmb":"1000","monthlyusagemb":10}`, idx+1)
+	}
+	var firstPage, secondPage map[string]any
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.getall", &firstPage)).
+		Reply(200).
+		BodyString(`{"code":"OK","loadbalancers":[` + strings.Join(lbs, ",") + `]}`)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.getall", &secondPage)).
+		Reply(200).
+		BodyString(`{"code":"OK","loadbalancers":[{"id":"999","customerid":"42","monthlyallocationmb":"1000","monthlyusagemb":10}]}`)
+
+	report, err := client.LoadBalancer().UsageReport(context.TODO(), LoadBalancerFilter{CustomerID: 42})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "UsageReport error")
+
+	assert.Equal(t, 42.0, firstPage["customerid"], "customerid")
+	assert.NotContains(t, firstPage, "resStart", "first page resStart")
+	assert.Equal(t, float64(loadBalancerUsagePageSize), secondPage["resStart"], "second page resStart")
+	assert.Len(t, report.Usage, loadBalancerUsagePageSize+1, "Usage")
+	assert.Equal(t, CustomerID(42), report.Usage[0].CustomerID, "CustomerID")
+}
+
+func TestLoadBalancerUsageReportIgnoredResultStart(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	lbs := make([]string, loadBalancerUsagePageSize)
+	for idx := range lbs {
+		lbs[idx] = fmt.Sprintf(`{"id":"%d","monthlyallocationmb":"1000","monthlyusagemb":10}`, idx+1)
+	}
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.getall", nil)).
+		Times(2).
+		Reply(200).
+		BodyString(`{"code":"OK","loadbalancers":[` + strings.Join(lbs, ",") + `]}`)
+
+	report, err := client.LoadBalancer().UsageReport(context.TODO(), LoadBalancerFilter{})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.Error(t, err, "UsageReport error when every page is the same")
+	assert.Nil(t, report, "report")
+}
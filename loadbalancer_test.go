@@ -0,0 +1,471 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestLoadBalancer(t *testing.T) LoadBalancer {
+	var resp loadBalancerGetResponse
+	err := json.Unmarshal([]byte(getTestDataString(t, "loadbalancer.get.responseBody.json")), &resp)
+	require.NoError(t, err, "json.Unmarshal")
+	lb := resp.LoadBalancer.ToLoadBalancer()
+	return lb
+}
+
+// clearServerPopulatedFields zeroes the fields that are not sent to loadbalancer.update.
+func clearServerPopulatedFields(lb LoadBalancer) LoadBalancer {
+	lb.CustomerID = 0
+	lb.Hostname = ""
+	lb.StdName = ""
+	lb.DateCreated = time.Time{}
+	lb.DateModified = time.Time{}
+	lb.Version = 0
+	lb.MonthlyAllocationMB = 0
+	lb.MonthlyUsageMB = 0
+	lb.TrafficRemainingMB = 0
+	lb.Status = ""
+	lb.Certificates = nil
+	lb.loaded = nil
+	lb.Backends = slices.Clone(lb.Backends)
+	for idx := range lb.Backends {
+		lb.Backends[idx].Created = time.Time{}
+		lb.Backends[idx].Modified = time.Time{}
+	}
+	return lb
+}
+
+func TestLoadBalancerGet(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	var req loadBalancerGetRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", &req)).
+		Reply(200).
+		BodyString(getTestDataString(t, "loadbalancer.get.responseBody.json"))
+
+	lb, err := client.LoadBalancer().Get(context.TODO(), 1234)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "Get error")
+
+	assert.Equal(t, LoadBalancerID(1234), req.Id, "request Id")
+	assert.Equal(t, LoadBalancerID(1234), lb.ID, "ID")
+	assert.Equal(t, "cdn-example", lb.Name, "Name")
+	assert.Equal(t, LoadBalancerTypeCDN, lb.Type, "Type")
+	assert.Equal(t, 7, lb.Version, "Version")
+	assert.Equal(t, []string{"www.example.com", "static.example.com"}, lb.Aliases, "Aliases")
+	assert.Equal(t, []int{80, 443}, lb.Ports, "Ports")
+	assert.Equal(t, []RegionID{1, 3}, lb.Regions, "Regions")
+	require.Len(t, lb.Backends, 2, "Backends")
+	assert.Equal(t, []int{443}, lb.Backends[0].PortMask, "Backends[0].PortMask")
+	assert.Equal(t, time.Unix(1735689600, 0), lb.Backends[0].Created, "Backends[0].Created")
+	assert.Equal(t, time.Unix(1738368000, 0), lb.Backends[0].Modified, "Backends[0].Modified")
+	assert.Equal(t, 24*time.Hour, lb.Backends[0].InRotationFor(time.Unix(1735689600+86400, 0)), "Backends[0].InRotationFor")
+	require.Len(t, lb.RefreshPatterns, 2, "RefreshPatterns")
+	assert.Equal(t, "^/static/", lb.RefreshPatterns[0].RegularExpression, "RefreshPatterns[0].RegularExpression")
+	assert.Equal(t, LoadBalancerHealthCheck{
+		Mode:           LoadBalancerCheckModeHTTP,
+		Host:           "www.example.com",
+		Method:         "GET",
+		Path:           "/healthz",
+		ExpectedStatus: 200,
+		BackendTLS:     true,
+	}, lb.HealthCheck, "HealthCheck")
+	assert.Equal(t, LoadBalancerCheckModeHTTP, lb.CheckMode, "deprecated CheckMode")
+}
+
+func TestLoadBalancerDeprecatedCheckMode(t *testing.T) {
+	lb := LoadBalancer{Type: LoadBalancerTypeTCP, CheckMode: LoadBalancerCheckModeTCP}
+	assert.Equal(t, LoadBalancerCheckModeTCP, newLoadBalancerUpdateRequest(lb).CheckMode, "CheckMode only")
+	assert.NoError(t, lb.Validate(), "CheckMode only")
+
+	lb.HealthCheck.Mode = LoadBalancerCheckModeHTTP
+	assert.Equal(t, LoadBalancerCheckModeHTTP, newLoadBalancerUpdateRequest(lb).CheckMode, "HealthCheck.Mode wins")
+
+	current := LoadBalancer{ID: 1234, HealthCheck: LoadBalancerHealthCheck{Mode: LoadBalancerCheckModeTCP}, CheckMode: LoadBalancerCheckModeTCP}
+	desired := LoadBalancer{CheckMode: LoadBalancerCheckModeTCP}
+	assert.False(t, PlanLoadBalancer(current, desired).HasChanges(), "CheckMode only is planned as HealthCheck.Mode")
+
+	loaded := getTestLoadBalancer(t)
+	require.Equal(t, LoadBalancerCheckModeHTTP, loaded.HealthCheck.Mode, "loaded HealthCheck.Mode")
+	changed := loaded
+	changed.CheckMode = LoadBalancerCheckModeTCP
+	assert.Equal(t, LoadBalancerCheckModeTCP, newLoadBalancerUpdateRequest(changed).CheckMode, "CheckMode changed after Get wins")
+	plan := PlanLoadBalancer(loaded, changed)
+	require.Len(t, plan.Changes, 1, "CheckMode changed after Get is planned: %v", plan.Changes)
+	assert.Equal(t, "healthcheck.mode", plan.Changes[0].Field, "planned field")
+	assert.Equal(t, LoadBalancerCheckModeHTTP, newLoadBalancerUpdateRequest(loaded).CheckMode, "unchanged CheckMode")
+}
+
+func TestLoadBalancerUpdateRoundTrip(t *testing.T) {
+	lb := getTestLoadBalancer(t)
+	lb.Name = "cdn-example-updated"
+
+	body, err := json.Marshal(newLoadBalancerUpdateRequest(lb))
+	require.NoError(t, err, "json.Marshal")
+
+	var sent existingLoadBalancer
+	require.NoError(t, json.Unmarshal(body, &sent), "json.Unmarshal")
+
+	sentLb := sent.ToLoadBalancer()
+
+	assert.Equal(t, clearServerPopulatedFields(lb), clearServerPopulatedFields(sentLb), "round trip")
+}
+
+func TestLoadBalancerUpdate(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := getTestDataString(t, "loadbalancer.get.responseBody.json")
+
+	var req map[string]any
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(responseBody)
+
+	lb, err := client.LoadBalancer().Get(context.TODO(), 1234)
+	require.NoError(t, err, "Get error")
+
+	lb.Aliases = nil
+	lb.AutoUpgradeHTTPS = false
+	_, err = client.LoadBalancer().Update(context.TODO(), *lb)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "Update error")
+
+	assert.Equal(t, []any{}, req["aliases"], "aliases are cleared")
+	assert.Equal(t, false, req["autoupgradehttps"], "autoupgradehttps is cleared")
+	assert.Equal(t, "FORCE", req["hostsource"], "hostsource")
+	assert.Equal(t, "www.example.com", req["hostsourceforcehost"], "hostsourceforcehost")
+	assert.Equal(t, "origin.example.com", req["backend_hostname_force"], "backend_hostname_force")
+	assert.Equal(t, []any{1.0, 3.0}, req["regions"], "regions")
+	assert.Len(t, req["refreshpatterns"], 2, "refreshpatterns")
+	require.Len(t, req["backends"], 2, "backends")
+	backend := req["backends"].([]any)[0].(map[string]any)
+	assert.Equal(t, "5d1c9a54-6f0e-4c43-9f4e-0b7a3c1d2e01", backend["uuid"], "backends[0].uuid")
+	assert.NotContains(t, backend, "created", "backends[0].created")
+	assert.NotContains(t, backend, "modified", "backends[0].modified")
+}
+
+func TestLoadBalancerAddACL(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := getTestDataString(t, "loadbalancer.get.responseBody.json")
+
+	var req loadBalancerUpdateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(responseBody)
+
+	_, err := client.LoadBalancer().AddACL(context.TODO(), 1234, LoadBalancerACL{
+		Data:   netip.MustParsePrefix("203.0.113.0/24"),
+		Action: LoadBalancerACLActionAllow,
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "AddACL error")
+
+	assert.Equal(t, []loadBalancerACL{
+		{ID: 11, Data: "198.51.100.0/24", Action: LoadBalancerACLActionAllow},
+		{Data: "203.0.113.0/24", Action: LoadBalancerACLActionAllow},
+	}, req.ACLs, "ACLs")
+}
+
+func TestLoadBalancerRemoveACLKeepsUnparseable(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := strings.Replace(getTestDataString(t, "loadbalancer.get.responseBody.json"), `"acl_action": "ALLOW"
+            }`, `"acl_action": "ALLOW"
+            },
+            {"id": "12", "acl_data": "198.51.100.*", "acl_action": "DENY"},
+            {"id": "13", "acl_data": "example.com", "acl_action": "DENY"}`, 1)
+
+	var removeReq, addReq loadBalancerUpdateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Times(2).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &removeReq)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &addReq)).
+		Reply(200).
+		BodyString(responseBody)
+
+	_, err := client.LoadBalancer().RemoveACL(context.TODO(), 1234, netip.MustParsePrefix("198.51.100.0/24"))
+	require.NoError(t, err, "RemoveACL error")
+	assert.Equal(t, []loadBalancerACL{
+		{ID: 12, Data: "198.51.100.*", Action: LoadBalancerACLActionDeny},
+		{ID: 13, Data: "example.com", Action: LoadBalancerACLActionDeny},
+	}, removeReq.ACLs, "RemoveACL keeps unparseable ACLs")
+
+	_, err = client.LoadBalancer().AddACL(context.TODO(), 1234, LoadBalancerACL{RawData: "example.com", Action: LoadBalancerACLActionAllow})
+	require.NoError(t, err, "AddACL error")
+	assert.Equal(t, []loadBalancerACL{
+		{ID: 11, Data: "198.51.100.0/24", Action: LoadBalancerACLActionAllow},
+		{ID: 12, Data: "198.51.100.*", Action: LoadBalancerACLActionDeny},
+		{ID: 13, Data: "example.com", Action: LoadBalancerACLActionAllow},
+	}, addReq.ACLs, "AddACL replaces only the matching unparseable ACL")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+}
+
+func TestLoadBalancerACLValidate(t *testing.T) {
+	acl := LoadBalancerACL{Action: LoadBalancerACLActionDeny}
+	assert.Error(t, acl.Validate(), "missing data")
+
+	acl = LoadBalancerACL{Data: netip.MustParsePrefix("192.0.2.0/24"), Action: "MAYBE"}
+	assert.Error(t, acl.Validate(), "invalid action")
+
+	prefix, err := parsePrefixOrAddr("192.0.2.7")
+	require.NoError(t, err, "parsePrefixOrAddr")
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.7/32"), prefix, "single address")
+}
+
+func TestLoadBalancerACLUnparseable(t *testing.T) {
+	var e existingLoadBalancer
+	err := json.Unmarshal([]byte(`{"id":"1234","acl":[{"id":"11","acl_data":"198.51.100.0/24","acl_action":"ALLOW"},{"id":"12","acl_data":"198.51.100.*","acl_action":"DENY"}]}`), &e)
+	require.NoError(t, err, "json.Unmarshal")
+
+	lb := e.ToLoadBalancer()
+	require.Len(t, lb.ACLs, 2, "ACLs")
+	assert.Equal(t, netip.MustParsePrefix("198.51.100.0/24"), lb.ACLs[0].Data, "ACLs[0].Data")
+	assert.False(t, lb.ACLs[1].Data.IsValid(), "ACLs[1].Data")
+	assert.Equal(t, "198.51.100.*", lb.ACLs[1].RawData, "ACLs[1].RawData")
+	assert.NoError(t, lb.ACLs[1].Validate(), "unparsed ACL from the server")
+
+	assert.Equal(t, loadBalancerACL{ID: 12, Data: "198.51.100.*", Action: LoadBalancerACLActionDeny},
+		convertLoadBalancerACL(lb.ACLs[1]), "unparsed ACL is sent back unchanged")
+}
+
+func TestLoadBalancerHealthCheckValidate(t *testing.T) {
+	hc := LoadBalancerHealthCheck{
+		Mode:           LoadBalancerCheckModeHTTP,
+		Method:         "GET",
+		Path:           "/healthz",
+		ExpectedStatus: 200,
+	}
+	assert.NoError(t, hc.Validate(LoadBalancerTypeHTTP), "HTTP check on HTTP load balancer")
+	assert.Error(t, hc.Validate(LoadBalancerTypeTCP), "HTTP options on TCP load balancer")
+	assert.Error(t, hc.Validate(LoadBalancerTypeUDP), "HTTP options on UDP load balancer")
+
+	hc.Mode = LoadBalancerCheckModeTCP
+	assert.Error(t, hc.Validate(LoadBalancerTypeHTTP), "HTTP options with TCP check mode")
+
+	hc = LoadBalancerHealthCheck{Mode: LoadBalancerCheckModeHTTP, Path: "healthz"}
+	assert.Error(t, hc.Validate(LoadBalancerTypeHTTP), "relative path")
+
+	hc = LoadBalancerHealthCheck{Mode: LoadBalancerCheckModeTCP, BackendTLS: true}
+	assert.NoError(t, hc.Validate(LoadBalancerTypeTCP), "TCP check on TCP load balancer")
+}
+
+func TestLoadBalancerCreateWithDiscovery(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	var req loadBalancerCreateRequest
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.create", &req)).
+		Reply(200).
+		BodyString(getTestDataString(t, "loadbalancer.get.responseBody.json"))
+
+	_, err := client.LoadBalancer().Create(context.TODO(), LoadBalancer{
+		Name:  "discovered",
+		Type:  LoadBalancerTypeHTTP,
+		Scope: LoadBalancerScopeGlobal,
+		Ports: []int{80},
+		Discovery: LoadBalancerDiscovery{
+			DNSName:     "backends.service.example.com",
+			MaxBackends: 8,
+		},
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "Create error")
+
+	assert.Equal(t, "backends.service.example.com", req.DiscoveryDNS, "DiscoveryDNS")
+	assert.Equal(t, 8, req.DiscoveryMax, "DiscoveryMax")
+	assert.Empty(t, req.Backends, "Backends")
+}
+
+func TestLoadBalancerDiscoveryValidate(t *testing.T) {
+	lb := LoadBalancer{
+		Discovery: LoadBalancerDiscovery{DNSName: "backends.example.com"},
+		Backends:  []LoadBalancerBackend{{Name: "static", Hostname: "192.0.2.10", Port: 80}},
+	}
+	assert.Error(t, lb.Validate(), "discovery with static backends")
+
+	assert.Error(t, LoadBalancerDiscovery{MaxBackends: 3}.Validate(), "MaxBackends without DNSName")
+	assert.Error(t, LoadBalancerDiscovery{DNSName: "-bad-.example.com"}.Validate(), "invalid DNSName")
+	assert.Error(t, LoadBalancerDiscovery{DNSName: "backends.example.com", MaxBackends: -1}.Validate(), "negative MaxBackends")
+	assert.NoError(t, LoadBalancerDiscovery{DNSName: "backends.example.com.", MaxBackends: 3}.Validate(), "valid discovery")
+}
+
+func TestLoadBalancerGetAllFilter(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+
+	var req map[string]any
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.getall", &req)).
+		Reply(200).
+		BodyString(`{"count":1,"loadbalancers":[{"id":"1234","name":"cdn-example","type":"CDN","status":"ACTIVE","datecreated":1735689600,"datemodified":1738368000}],"code":"OK","message":"Load balancers retrieved"}`)
+
+	lbs, err := client.LoadBalancer().GetAll(context.TODO(), LoadBalancerFilter{
+		Status:       LoadBalancerStatusActive,
+		Type:         []LoadBalancerType{LoadBalancerTypeCDN, LoadBalancerTypeHTTP},
+		LastModified: time.Unix(1735689600, 0),
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "GetAll error")
+
+	assert.Equal(t, "ACTIVE", req["status"], "status")
+	assert.Equal(t, []any{"CDN", "HTTP"}, req["type"], "type")
+	assert.Equal(t, 1735689600.0, req["lastmodified"], "lastmodified")
+
+	require.Len(t, lbs, 1, "lbs")
+	assert.Equal(t, LoadBalancerStatusActive, lbs[0].Status, "Status")
+}
+
+func TestLoadBalancerUpdateIfUnmodifiedConflict(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	lb := getTestLoadBalancer(t)
+
+	var req map[string]any
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(`{"code":"CONFLICT","message":"Load balancer has been modified"}`)
+
+	_, err := client.LoadBalancer().UpdateIfUnmodified(context.TODO(), lb)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.True(t, errors.Is(err, ErrConflict), "UpdateIfUnmodified error should be ErrConflict: %v", err)
+	assert.Equal(t, 7.0, req["version"], "version")
+}
+
+func TestLoadBalancerUpdateWithoutVersion(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	lb := getTestLoadBalancer(t)
+
+	var req map[string]any
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", &req)).
+		Reply(200).
+		BodyString(`{"code":"FAULT","message":"Load balancer version mismatch"}`)
+
+	_, err := client.LoadBalancer().Update(context.TODO(), lb)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.Error(t, err, "Update error")
+	assert.False(t, errors.Is(err, ErrConflict), "Update error should not be ErrConflict")
+	assert.NotContains(t, req, "version", "version")
+}
+
+func TestLoadBalancerUpdateFuncDoesNotRetryOtherErrors(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := getTestDataString(t, "loadbalancer.get.responseBody.json")
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", nil)).
+		Reply(200).
+		BodyString(`{"code":"FAULT","message":"Unsupported API version"}`)
+
+	calls := 0
+	_, err := client.LoadBalancer().UpdateFunc(context.TODO(), 1234, func(lb *LoadBalancer) error {
+		calls++
+		return nil
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.Error(t, err, "UpdateFunc error")
+	assert.False(t, errors.Is(err, ErrConflict), "an error mentioning a version is not ErrConflict: %v", err)
+	assert.Equal(t, 1, calls, "calls")
+}
+
+func TestLoadBalancerUpdateFuncGivesUp(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := getTestDataString(t, "loadbalancer.get.responseBody.json")
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Times(loadBalancerUpdateFuncMaxAttempts).
+		Reply(200).
+		BodyString(responseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.update", nil)).
+		Times(loadBalancerUpdateFuncMaxAttempts).
+		Reply(200).
+		BodyString(`{"code":"CONFLICT","message":"Load balancer has been modified"}`)
+
+	calls := 0
+	_, err := client.LoadBalancer().UpdateFunc(context.TODO(), 1234, func(lb *LoadBalancer) error {
+		calls++
+		lb.Name = "renamed"
+		return nil
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.True(t, errors.Is(err, ErrConflict), "UpdateFunc error should be ErrConflict: %v", err)
+	assert.Equal(t, loadBalancerUpdateFuncMaxAttempts, calls, "calls")
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decimalMaxScale is the most fractional digits a Decimal keeps.
+const decimalMaxScale = 8
+
+// Decimal is an exact decimal number, used for money so that amounts are never rounded by a float.
+// The zero value is 0.
+type Decimal struct {
+	unscaled int64
+	scale    uint8
+}
+
+// ErrDecimalOverflow is returned when a Decimal result does not fit in its 64-bit representation.
+var ErrDecimalOverflow = errors.New("decimal overflow")
+
+// ParseDecimal parses a decimal number such as "12", "-0.5", "1234.5678" or, as JSON numbers may be written, "1.5e2".
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		exponent, err = strconv.Atoi(s[i+1:])
+		if err != nil || mantissa == "" {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+	digits, fraction, hasFraction := strings.Cut(mantissa, ".")
+	if hasFraction && (fraction == "" || strings.ContainsAny(fraction, "+-")) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	// the value is digits+fraction scaled down by scale digits
+	unscaledDigits, scale := digits+fraction, len(fraction)-exponent
+	if scale < 0 {
+		if -scale > 18 {
+			return Decimal{}, fmt.Errorf("decimal %q: %w", s, ErrDecimalOverflow)
+		}
+		unscaledDigits += strings.Repeat("0", -scale)
+		scale = 0
+	}
+	for scale > 0 && strings.HasSuffix(unscaledDigits, "0") {
+		unscaledDigits = unscaledDigits[:len(unscaledDigits)-1]
+		scale--
+	}
+	if scale > decimalMaxScale {
+		return Decimal{}, fmt.Errorf("decimal %q has more than %d fractional digits", s, decimalMaxScale)
+	}
+	unscaled, err := strconv.ParseInt(unscaledDigits, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return Decimal{}, fmt.Errorf("decimal %q: %w", s, ErrDecimalOverflow)
+	}
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: %w", s, err)
+	}
+	return Decimal{unscaled: unscaled, scale: uint8(scale)}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on error. It is intended for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) String() string {
+	if d.scale == 0 {
+		return strconv.FormatInt(d.unscaled, 10)
+	}
+	sign := ""
+	abs := d.unscaled
+	if abs < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	s := strconv.FormatInt(abs, 10)
+	if len(s) <= int(d.scale) {
+		s = strings.Repeat("0", int(d.scale)-len(s)+1) + s
+	}
+	return sign + s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+}
+
+// StringFixed formats d with exactly places fractional digits, e.g. 2 for cents.
+// Extra digits are rounded half to even, so 0.125 is "0.12" and 0.135 is "0.14".
+func (d Decimal) StringFixed(places int) string {
+	places = max(places, 0)
+	if int(d.scale) > places {
+		d = d.round(uint8(places))
+	}
+	digits, fraction, _ := strings.Cut(d.String(), ".")
+	fraction += strings.Repeat("0", places-len(fraction))
+	if places == 0 {
+		return digits
+	}
+	return digits + "." + fraction
+}
+
+func (d Decimal) IsZero() bool {
+	return d.unscaled == 0
+}
+
+func (d Decimal) Sign() int {
+	switch {
+	case d.unscaled < 0:
+		return -1
+	case d.unscaled > 0:
+		return 1
+	}
+	return 0
+}
+
+// round returns d rounded half to even to scale, which must not be greater than d.scale.
+func (d Decimal) round(scale uint8) Decimal {
+	divisor := int64(math.Pow10(int(d.scale - scale)))
+	quotient, remainder := d.unscaled/divisor, d.unscaled%divisor
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if 2*remainder > divisor || (2*remainder == divisor && quotient%2 != 0) {
+		quotient += int64(d.Sign())
+	}
+	return Decimal{unscaled: quotient, scale: scale}
+}
+
+// rescale returns the unscaled value of d at scale, which must not be less than d.scale.
+func (d Decimal) rescale(scale uint8) (int64, error) {
+	factor := int64(math.Pow10(int(scale - d.scale)))
+	if d.unscaled > math.MaxInt64/factor || d.unscaled < math.MinInt64/factor {
+		return 0, ErrDecimalOverflow
+	}
+	return d.unscaled * factor, nil
+}
+
+// Add returns d + other, or ErrDecimalOverflow if the sum does not fit.
+func (d Decimal) Add(other Decimal) (Decimal, error) {
+	scale := max(d.scale, other.scale)
+	a, err := d.rescale(scale)
+	if err != nil {
+		return Decimal{}, err
+	}
+	b, err := other.rescale(scale)
+	if err != nil {
+		return Decimal{}, err
+	}
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{unscaled: a + b, scale: scale}.normalize(), nil
+}
+
+// normalize removes trailing fractional zeros, so that equal values are also equal structs.
+func (d Decimal) normalize() Decimal {
+	for d.scale > 0 && d.unscaled%10 == 0 {
+		d.unscaled /= 10
+		d.scale--
+	}
+	return d
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: -d.unscaled, scale: d.scale}
+}
+
+// Sub returns d - other, or ErrDecimalOverflow if the difference does not fit.
+func (d Decimal) Sub(other Decimal) (Decimal, error) {
+	if other.unscaled == math.MinInt64 {
+		// -other does not fit
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return d.Add(other.Neg())
+}
+
+// Cmp returns -1, 0 or 1 when d is less than, equal to or greater than other. It cannot overflow.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.bigRescale(other.scale).Cmp(other.bigRescale(d.scale))
+}
+
+// bigRescale returns the unscaled value of d at max(d.scale, scale) as a big.Int.
+func (d Decimal) bigRescale(scale uint8) *big.Int {
+	value := big.NewInt(d.unscaled)
+	if scale > d.scale {
+		factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-d.scale)), nil)
+		value.Mul(value, factor)
+	}
+	return value
+}
+
+// UnmarshalJSON accepts a JSON number or a string, without going through a float.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*d = Decimal{}
+			return nil
+		}
+	} else {
+		s = string(data)
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// Money is an amount in an ISO 4217 currency such as "AUD".
+type Money struct {
+	Amount   Decimal
+	Currency string
+}
+
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount.StringFixed(2)
+	}
+	return m.Amount.StringFixed(2) + " " + m.Currency
+}
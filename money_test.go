@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for input, expected := range map[string]string{
+		"0":         "0",
+		"12":        "12",
+		"12.50":     "12.5",
+		"-0.05":     "-0.05",
+		"1234.5678": "1234.5678",
+		".5":        "0.5",
+		"1e2":       "100",
+		"1.5E2":     "150",
+		"-2.5e-3":   "-0.0025",
+		"120e-1":    "12",
+	} {
+		d, err := ParseDecimal(input)
+		require.NoError(t, err, "ParseDecimal(%q) error", input)
+		assert.Equal(t, expected, d.String(), "ParseDecimal(%q)", input)
+	}
+
+	for _, input := range []string{"", "abc", "1.", "1.2.3", "1e", "e5", "1e2.5", "0.123456789", "1e-9"} {
+		_, err := ParseDecimal(input)
+		assert.Error(t, err, "ParseDecimal(%q) error", input)
+	}
+
+	for _, input := range []string{"9223372036854775808", "1e19", "92233720368.54775808"} {
+		_, err := ParseDecimal(input)
+		assert.True(t, errors.Is(err, ErrDecimalOverflow), "ParseDecimal(%q) error %v is ErrDecimalOverflow", input, err)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := MustParseDecimal("0.1")
+	b := MustParseDecimal("0.2")
+	sum, err := a.Add(b)
+	require.NoError(t, err, "0.1 + 0.2 error")
+	assert.Equal(t, "0.3", sum.String(), "0.1 + 0.2")
+	assert.Equal(t, 0, sum.Cmp(MustParseDecimal("0.30")), "0.1 + 0.2 == 0.30")
+	difference, err := a.Sub(b)
+	require.NoError(t, err, "0.1 - 0.2 error")
+	assert.Equal(t, "-0.1", difference.String(), "0.1 - 0.2")
+	assert.Equal(t, -1, a.Cmp(b), "0.1 < 0.2")
+	sum, err = MustParseDecimal("0.5").Add(MustParseDecimal("0.5"))
+	require.NoError(t, err, "0.5 + 0.5 error")
+	assert.Equal(t, MustParseDecimal("1"), sum, "0.5 + 0.5 is normalized")
+}
+
+func TestDecimalOverflow(t *testing.T) {
+	large := MustParseDecimal("92233720369")
+	_, err := large.Add(MustParseDecimal("0.00000001"))
+	assert.True(t, errors.Is(err, ErrDecimalOverflow), "rescaling %s to 8 places: %v", large, err)
+
+	maxValue := MustParseDecimal("9223372036854775807")
+	_, err = maxValue.Add(MustParseDecimal("1"))
+	assert.True(t, errors.Is(err, ErrDecimalOverflow), "max + 1: %v", err)
+	_, err = maxValue.Neg().Sub(MustParseDecimal("2"))
+	assert.True(t, errors.Is(err, ErrDecimalOverflow), "-max - 2: %v", err)
+
+	assert.Equal(t, 1, large.Cmp(MustParseDecimal("0.00000001")), "Cmp does not overflow")
+	assert.Equal(t, -1, large.Neg().Cmp(MustParseDecimal("-0.00000001")), "Cmp does not overflow for negative values")
+}
+
+func TestDecimalStringFixed(t *testing.T) {
+	for input, expected := range map[string]string{
+		"12.3":    "12.30",
+		"12":      "12.00",
+		"12.344":  "12.34",
+		"12.346":  "12.35",
+		"0.125":   "0.12",
+		"0.135":   "0.14",
+		"0.1251":  "0.13",
+		"-0.125":  "-0.12",
+		"-0.135":  "-0.14",
+		"-0.001":  "0.00",
+		"9.995":   "10.00",
+		"-9.9951": "-10.00",
+	} {
+		assert.Equal(t, expected, MustParseDecimal(input).StringFixed(2), "%s.StringFixed(2)", input)
+	}
+	assert.Equal(t, "2", MustParseDecimal("2.5").StringFixed(0), "2.5.StringFixed(0)")
+	assert.Equal(t, "4", MustParseDecimal("3.5").StringFixed(0), "3.5.StringFixed(0)")
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		Number Decimal `json:"number"`
+		String Decimal `json:"string"`
+		Empty  Decimal `json:"empty"`
+		Null   Decimal `json:"null"`
+	}
+	err := json.Unmarshal([]byte(`{"number":19.99,"string":"0.10","empty":"","null":null}`), &v)
+	require.NoError(t, err, "json.Unmarshal")
+	assert.Equal(t, MustParseDecimal("19.99"), v.Number, "number")
+	assert.Equal(t, MustParseDecimal("0.1"), v.String, "string")
+	assert.True(t, v.Empty.IsZero(), "empty")
+	assert.True(t, v.Null.IsZero(), "null")
+
+	data, err := json.Marshal(v.Number)
+	require.NoError(t, err, "json.Marshal")
+	assert.Equal(t, `"19.99"`, string(data), "json.Marshal")
+}
@@ -1,3 +1,83 @@
 package api
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rackcorpcloud/rackcorp-api-go/internal"
+)
+
 type NetworkID int
+
+func (id *NetworkID) UnmarshalJSON(data []byte) error {
+	return internal.UnmarshalJSONInt(id, data)
+}
+
+// Network is a customer network, e.g. a private VLAN that local scope load balancers can be attached to.
+type Network struct {
+	ID         NetworkID
+	CustomerID CustomerID
+	Name       string
+	VLANID     int
+	Network    string
+	Netmask    string
+	Gateway    string
+}
+
+type network struct {
+	ID         NetworkID        `json:"id"`
+	CustomerID CustomerID       `json:"customerId"`
+	Name       string           `json:"name"`
+	VLANID     internal.JSONInt `json:"vlanId"`
+	Network    string           `json:"network"`
+	Netmask    string           `json:"netmask"`
+	Gateway    string           `json:"gateway"`
+}
+
+func (n network) ToNetwork() Network {
+	return Network{
+		ID:         n.ID,
+		CustomerID: n.CustomerID,
+		Name:       n.Name,
+		VLANID:     n.VLANID.Int(),
+		Network:    n.Network,
+		Netmask:    n.Netmask,
+		Gateway:    n.Gateway,
+	}
+}
+
+type networkGetResponse struct {
+	response
+	Network *network `json:"network"`
+}
+
+type NetworkClient interface {
+	Get(ctx context.Context, id NetworkID) (*Network, error)
+}
+
+type networkClient struct {
+	c *client
+}
+
+var _ NetworkClient = (*networkClient)(nil)
+
+func (nc *networkClient) Get(ctx context.Context, id NetworkID) (*Network, error) {
+	if id == 0 {
+		return nil, errors.New("id parameter is required")
+	}
+
+	var resp networkGetResponse
+	err := nc.c.httpRestJson(ctx, http.MethodGet, fmt.Sprintf("networks/%d", id), emptyRequest{}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network for network Id '%d': %w", id, err)
+	}
+
+	if !resp.IsOK() || resp.Network == nil {
+		return nil, newApiError(resp.response, nil)
+	}
+
+	n := resp.Network.ToNetwork()
+	return &n, nil
+}
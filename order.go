@@ -78,9 +78,16 @@ type ConfirmedOrder struct {
 	ContractIds []string
 }
 
+// CreatedOrder is an order awaiting OrderConfirm. The costs are as quoted by Rackcorp and
+// Changes are the parsed lines of ChangeText.
 type CreatedOrder struct {
-	OrderId    string
-	ChangeText string
+	OrderId       string
+	ChangeText    string
+	Changes       []OrderChange
+	Cost          Money
+	NetCost       Money
+	RetailCost    Money
+	RetailNetCost Money
 }
 
 type orderConfirmRequest struct {
@@ -103,9 +110,13 @@ type orderCreateRequest struct {
 
 type orderCreateResponse struct {
 	response
-	OrderId    int    `json:"orderId"`
-	ChangeText string `json:"changeTxt"`
-	// TODO cost, currency, netCost, retailCost, retailNetCost
+	OrderId       int     `json:"orderId"`
+	ChangeText    string  `json:"changeTxt"`
+	Cost          Decimal `json:"cost"`
+	Currency      string  `json:"currency"`
+	NetCost       Decimal `json:"netCost"`
+	RetailCost    Decimal `json:"retailCost"`
+	RetailNetCost Decimal `json:"retailNetCost"`
 }
 
 type orderGetResponse struct {
@@ -176,6 +187,11 @@ func (c *client) OrderConfirm(ctx context.Context, orderId string) (*ConfirmedOr
 		return nil, errors.New("orderId parameter is required")
 	}
 
+	order, policy, err := c.checkOrderCostPolicy(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
 	req := &orderConfirmRequest{
 		legacyRequest: legacyRequest{
 			Command: "order.confirm",
@@ -183,15 +199,23 @@ func (c *client) OrderConfirm(ctx context.Context, orderId string) (*ConfirmedOr
 		OrderId: orderId,
 	}
 	var resp orderConfirmResponse
-	err := c.httpLegacyJson(ctx, req, &resp)
+	err = c.httpLegacyJson(ctx, req, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to confirm order: %w", err)
 	}
 
-	if resp.Code != "OK" || len(resp.ContractIds) == 0 {
+	if resp.Code != "OK" {
+		c.orderConfirmFailed(ctx, policy, order)
+		return nil, newApiError(resp.response, nil)
+	}
+	if len(resp.ContractIds) == 0 {
+		// Rackcorp accepted the confirmation without saying what it created, so the order may still be
+		// provisioned and the cost reserved by the policy is kept
 		return nil, newApiError(resp.response, nil)
 	}
 
+	c.orderConfirmed(ctx, policy, order)
+
 	return &ConfirmedOrder{
 		ContractIds: sliceItoa(resp.ContractIds),
 	}, nil
@@ -225,10 +249,24 @@ func (c *client) OrderCreate(ctx context.Context, productCode string, customerId
 		return nil, newApiError(resp.response, nil)
 	}
 
-	return &CreatedOrder{
-		OrderId:    strconv.Itoa(resp.OrderId),
-		ChangeText: resp.ChangeText,
-	}, nil
+	created := &CreatedOrder{
+		OrderId:       strconv.Itoa(resp.OrderId),
+		ChangeText:    resp.ChangeText,
+		Changes:       ParseOrderChangeText(resp.ChangeText, resp.Currency),
+		Cost:          Money{Amount: resp.Cost, Currency: resp.Currency},
+		NetCost:       Money{Amount: resp.NetCost, Currency: resp.Currency},
+		RetailCost:    Money{Amount: resp.RetailCost, Currency: resp.Currency},
+		RetailNetCost: Money{Amount: resp.RetailNetCost, Currency: resp.Currency},
+	}
+
+	c.addPendingOrder(PendingOrder{
+		OrderId:     created.OrderId,
+		ProductCode: productCode,
+		CustomerId:  customerId,
+		Created:     created,
+	})
+
+	return created, nil
 }
 
 func (c *client) OrderGet(ctx context.Context, orderId string) (*Order, error) {
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+)
+
+// OrderChange is a line of the ChangeText of a created order, e.g. "Add NEW IPV6: 16 ($0.00)"
+// is Action "Add", Qualifier "NEW", Item "IPV6", Value "16" and a Cost of 0.
+// Lines that are not in this form only have Text set.
+type OrderChange struct {
+	Text      string
+	Action    string
+	Qualifier string
+	Item      string
+	Value     string
+	Cost      Money
+	HasCost   bool
+}
+
+var orderChangeRegexp = regexp.MustCompile(`^(\S+)(?: (NEW|EXISTING|OLD))? ([^:]+):\s*(.*?)(?:\s*\(([^()]*)\))?$`)
+
+// ParseOrderChangeText parses the lines of an order ChangeText. Costs are in currency.
+func ParseOrderChangeText(changeText string, currency string) []OrderChange {
+	var changes []OrderChange
+	for _, line := range strings.Split(changeText, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		change := OrderChange{Text: line}
+		if m := orderChangeRegexp.FindStringSubmatch(line); m != nil {
+			change.Action = m[1]
+			change.Qualifier = m[2]
+			change.Item = strings.TrimSpace(m[3])
+			change.Value = m[4]
+			if amount, ok := parseOrderChangeCost(m[5]); ok {
+				change.Cost = Money{Amount: amount, Currency: currency}
+				change.HasCost = true
+			} else if m[5] != "" {
+				change.Value = strings.TrimSpace(m[4] + " (" + m[5] + ")")
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// parseOrderChangeCost parses a cost such as "$12.50", "-$5.00" or "$-5.00".
+func parseOrderChangeCost(s string) (Decimal, bool) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if !strings.HasPrefix(s, "$") {
+		return Decimal{}, false
+	}
+	s = strings.ReplaceAll(strings.TrimPrefix(s, "$"), ",", "")
+	amount, err := ParseDecimal(s)
+	if err != nil {
+		return Decimal{}, false
+	}
+	if negative {
+		amount = amount.Neg()
+	}
+	return amount, true
+}
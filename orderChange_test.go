@@ -0,0 +1,52 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrderChangeText(t *testing.T) {
+	changes := ParseOrderChangeText("Add NEW SUPPORT: SUPPORTSTD ($0.00)\nAdd NEW IPV6: 16 ($0.00)\nChange CPU: 1 -> 2 ($11.00)\nRemove OLD STORAGEGB: 20 (-$1.50)\nSomething else\n", "AUD")
+
+	assert.Equal(t, []OrderChange{
+		{
+			Text:      "Add NEW SUPPORT: SUPPORTSTD ($0.00)",
+			Action:    "Add",
+			Qualifier: "NEW",
+			Item:      "SUPPORT",
+			Value:     "SUPPORTSTD",
+			Cost:      Money{Currency: "AUD"},
+			HasCost:   true,
+		},
+		{
+			Text:      "Add NEW IPV6: 16 ($0.00)",
+			Action:    "Add",
+			Qualifier: "NEW",
+			Item:      "IPV6",
+			Value:     "16",
+			Cost:      Money{Currency: "AUD"},
+			HasCost:   true,
+		},
+		{
+			Text:    "Change CPU: 1 -> 2 ($11.00)",
+			Action:  "Change",
+			Item:    "CPU",
+			Value:   "1 -> 2",
+			Cost:    Money{Amount: MustParseDecimal("11"), Currency: "AUD"},
+			HasCost: true,
+		},
+		{
+			Text:      "Remove OLD STORAGEGB: 20 (-$1.50)",
+			Action:    "Remove",
+			Qualifier: "OLD",
+			Item:      "STORAGEGB",
+			Value:     "20",
+			Cost:      Money{Amount: MustParseDecimal("-1.5"), Currency: "AUD"},
+			HasCost:   true,
+		},
+		{
+			Text: "Something else",
+		},
+	}, changes, "ParseOrderChangeText")
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrOrderRefused is wrapped by the errors of OrderConfirm when the order cost policy refuses an order.
+var ErrOrderRefused = errors.New("order refused by cost policy")
+
+// OrderRefusedError is returned by BudgetPolicy, and can be returned by other policies, to refuse an order.
+type OrderRefusedError struct {
+	OrderId string
+	Reason  string
+}
+
+func (e *OrderRefusedError) Error() string {
+	return fmt.Sprintf("order %s refused by cost policy: %s", e.OrderId, e.Reason)
+}
+
+func (e *OrderRefusedError) Unwrap() error {
+	return ErrOrderRefused
+}
+
+// PendingOrder is an order about to be confirmed. ProductCode, CustomerId and Created are only known for
+// orders created with OrderCreate by the same client, otherwise Created is nil.
+type PendingOrder struct {
+	OrderId     string
+	ProductCode string
+	CustomerId  string
+	Created     *CreatedOrder
+}
+
+// OrderCostPolicy is checked by OrderConfirm before an order is confirmed. Returning an error refuses the order.
+type OrderCostPolicy interface {
+	CheckOrder(ctx context.Context, order PendingOrder) error
+}
+
+// OrderConfirmedRecorder is optionally implemented by an OrderCostPolicy to be told about confirmed orders,
+// e.g. to track the cost confirmed per day.
+type OrderConfirmedRecorder interface {
+	OrderConfirmed(ctx context.Context, order PendingOrder)
+}
+
+// OrderConfirmFailedRecorder is optionally implemented by an OrderCostPolicy to be told when Rackcorp rejects
+// the confirmation of an order the policy allowed, e.g. to release the cost reserved by CheckOrder. It is
+// not called when the outcome of the confirmation is unknown, e.g. on a network error.
+type OrderConfirmFailedRecorder interface {
+	OrderConfirmFailed(ctx context.Context, order PendingOrder)
+}
+
+// OrderCostPolicyFunc adapts a function to an OrderCostPolicy, e.g. to ask an approval service.
+type OrderCostPolicyFunc func(ctx context.Context, order PendingOrder) error
+
+func (f OrderCostPolicyFunc) CheckOrder(ctx context.Context, order PendingOrder) error {
+	return f(ctx, order)
+}
+
+// BudgetPolicy limits the Cost of the orders confirmed by a client. Zero limits are not checked.
+// Orders whose cost is unknown, because they were not created by the same client, are refused when
+// any limit is set. Costs in a currency other than the limit's are refused.
+//
+// CheckOrder reserves the cost of an allowed order against MaxDailyCost, so that concurrent confirmations
+// cannot exceed it together. The reservation is released if Rackcorp rejects the confirmation. An order
+// that is checked again, e.g. when retrying after a network error, is checked against the current limits
+// like any other, and its reservation is released if it is refused.
+type BudgetPolicy struct {
+	MaxOrderCost        Money
+	MaxDailyCost        Money
+	AllowedProductCodes []string
+	// Now returns the current time, whose calendar day is used for MaxDailyCost. It defaults to time.Now.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	day       string
+	dailyCost Decimal
+	reserved  map[string]budgetReservation
+}
+
+// budgetReservation is the cost of an order reserved by BudgetPolicy.CheckOrder on day.
+type budgetReservation struct {
+	day    string
+	amount Decimal
+}
+
+var _ OrderCostPolicy = (*BudgetPolicy)(nil)
+var _ OrderConfirmedRecorder = (*BudgetPolicy)(nil)
+var _ OrderConfirmFailedRecorder = (*BudgetPolicy)(nil)
+
+func (p *BudgetPolicy) CheckOrder(ctx context.Context, order PendingOrder) error {
+	err := p.checkOrder(order)
+	if err != nil {
+		p.OrderConfirmFailed(ctx, order)
+	}
+	return err
+}
+
+func (p *BudgetPolicy) checkOrder(order PendingOrder) error {
+	refuse := func(format string, args ...any) error {
+		return &OrderRefusedError{OrderId: order.OrderId, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if len(p.AllowedProductCodes) > 0 && !slices.Contains(p.AllowedProductCodes, order.ProductCode) {
+		if order.ProductCode == "" {
+			return refuse("product code is unknown")
+		}
+		return refuse("product code %s is not allowed", order.ProductCode)
+	}
+
+	if p.MaxOrderCost.Amount.IsZero() && p.MaxDailyCost.Amount.IsZero() {
+		return nil
+	}
+	if order.Created == nil {
+		return refuse("cost is unknown")
+	}
+	cost := order.Created.Cost
+
+	if !p.MaxOrderCost.Amount.IsZero() {
+		if cost.Currency != p.MaxOrderCost.Currency {
+			return refuse("cost %s is not in %s", cost, p.MaxOrderCost.Currency)
+		}
+		if cost.Amount.Cmp(p.MaxOrderCost.Amount) > 0 {
+			return refuse("cost %s exceeds the per-order maximum of %s", cost, p.MaxOrderCost)
+		}
+	}
+
+	if !p.MaxDailyCost.Amount.IsZero() {
+		if cost.Currency != p.MaxDailyCost.Currency {
+			return refuse("cost %s is not in %s", cost, p.MaxDailyCost.Currency)
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		total := p.dailyCostLocked()
+		reservation, reserved := p.reserved[order.OrderId]
+		// a cost reserved today is already part of the total
+		reserved = reserved && reservation.day == p.day
+		if !reserved {
+			var err error
+			total, err = total.Add(cost.Amount)
+			if err != nil {
+				return refuse("cost %s cannot be added to today's total: %v", cost, err)
+			}
+		}
+		if total.Cmp(p.MaxDailyCost.Amount) > 0 {
+			return refuse("cost %s would bring today's total to %s, exceeding the daily maximum of %s",
+				cost, Money{Amount: total, Currency: cost.Currency}, p.MaxDailyCost)
+		}
+		if reserved {
+			return nil
+		}
+		p.dailyCost = total
+		if p.reserved == nil {
+			p.reserved = map[string]budgetReservation{}
+		}
+		p.reserved[order.OrderId] = budgetReservation{day: p.day, amount: cost.Amount}
+	}
+
+	return nil
+}
+
+// OrderConfirmed keeps the cost reserved by CheckOrder, or adds the cost of an order that was not reserved
+// if it is in the currency of MaxDailyCost.
+func (p *BudgetPolicy) OrderConfirmed(_ context.Context, order PendingOrder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.reserved[order.OrderId]; ok {
+		delete(p.reserved, order.OrderId)
+		return
+	}
+	if order.Created == nil {
+		return
+	}
+	cost := order.Created.Cost
+	if p.MaxDailyCost.Currency != "" && cost.Currency != p.MaxDailyCost.Currency {
+		return
+	}
+	// a total that would overflow cannot be tracked, and CheckOrder refuses further orders at that point anyway
+	if total, err := p.dailyCostLocked().Add(cost.Amount); err == nil {
+		p.dailyCost = total
+	}
+}
+
+// OrderConfirmFailed releases the cost reserved by CheckOrder.
+func (p *BudgetPolicy) OrderConfirmFailed(_ context.Context, order PendingOrder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reservation, ok := p.reserved[order.OrderId]
+	if !ok {
+		return
+	}
+	if reservation.day == p.dailyDayLocked() {
+		// the reserved amount was added to dailyCost, so subtracting it cannot overflow
+		p.dailyCost, _ = p.dailyCost.Sub(reservation.amount)
+	}
+	delete(p.reserved, order.OrderId)
+}
+
+// DailyCost returns the cost of the orders confirmed, or being confirmed, today.
+func (p *BudgetPolicy) DailyCost() Decimal {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dailyCostLocked()
+}
+
+// dailyCostLocked returns the cost confirmed today, resetting it when the day has changed.
+func (p *BudgetPolicy) dailyCostLocked() Decimal {
+	p.dailyDayLocked()
+	return p.dailyCost
+}
+
+// dailyDayLocked returns the current day, resetting the daily cost when the day has changed.
+func (p *BudgetPolicy) dailyDayLocked() string {
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	day := now().Format(time.DateOnly)
+	if day != p.day {
+		// keep the reservations of the previous day, which were counted then, until they are confirmed
+		for orderId, reservation := range p.reserved {
+			if reservation.day != p.day {
+				delete(p.reserved, orderId)
+			}
+		}
+		p.day = day
+		p.dailyCost = Decimal{}
+	}
+	return p.day
+}
+
+// checkOrderCostPolicy checks the order against the client's policy, logging the decision. It returns the
+// policy checked, which must be told about the outcome with orderConfirmed or orderConfirmFailed.
+func (c *client) checkOrderCostPolicy(ctx context.Context, orderId string) (PendingOrder, OrderCostPolicy, error) {
+	c.ordersMu.Lock()
+	pending, ok := c.pendingOrders[orderId]
+	policy := c.orderCostPolicy
+	c.ordersMu.Unlock()
+	order := pending.order
+	if !ok {
+		order = PendingOrder{OrderId: orderId}
+	}
+
+	if policy == nil {
+		return order, nil, nil
+	}
+
+	logger := DefaultLogger.With(
+		slog.String("order.id", orderId),
+		slog.String("order.product_code", order.ProductCode),
+	)
+	if order.Created != nil {
+		logger = logger.With(slog.String("order.cost", order.Created.Cost.String()))
+	}
+
+	err := policy.CheckOrder(ctx, order)
+	if err != nil {
+		withError(logger, err).Warn("order refused by cost policy")
+		if !errors.Is(err, ErrOrderRefused) {
+			err = fmt.Errorf("%w: %w", ErrOrderRefused, err)
+		}
+		return order, nil, err
+	}
+	logger.Info("order allowed by cost policy")
+	return order, policy, nil
+}
+
+// orderConfirmed forgets a confirmed order and records it with the policy.
+func (c *client) orderConfirmed(ctx context.Context, policy OrderCostPolicy, order PendingOrder) {
+	c.ordersMu.Lock()
+	delete(c.pendingOrders, order.OrderId)
+	c.ordersMu.Unlock()
+
+	if recorder, ok := policy.(OrderConfirmedRecorder); ok {
+		recorder.OrderConfirmed(ctx, order)
+	}
+}
+
+// orderConfirmFailed tells the policy that Rackcorp rejected the confirmation of the order.
+func (c *client) orderConfirmFailed(ctx context.Context, policy OrderCostPolicy, order PendingOrder) {
+	if recorder, ok := policy.(OrderConfirmFailedRecorder); ok {
+		recorder.OrderConfirmFailed(ctx, order)
+	}
+}
+
+// pendingOrderTTL is how long a created order is remembered for OrderConfirm. Older orders are forgotten
+// when another order is created, and are then checked as orders of unknown cost.
+const pendingOrderTTL = 24 * time.Hour
+
+// maxPendingOrders bounds the created orders remembered for OrderConfirm, forgetting the oldest first.
+const maxPendingOrders = 1000
+
+// pendingOrder is a PendingOrder remembered from OrderCreate until it is confirmed.
+type pendingOrder struct {
+	order   PendingOrder
+	created time.Time
+}
+
+// addPendingOrder remembers a created order for OrderConfirm, forgetting expired orders.
+func (c *client) addPendingOrder(order PendingOrder) {
+	now := time.Now()
+	c.ordersMu.Lock()
+	defer c.ordersMu.Unlock()
+	if c.pendingOrders == nil {
+		c.pendingOrders = map[string]pendingOrder{}
+	}
+	var oldestId string
+	var oldest time.Time
+	for orderId, pending := range c.pendingOrders {
+		if now.Sub(pending.created) > pendingOrderTTL {
+			delete(c.pendingOrders, orderId)
+		} else if oldestId == "" || pending.created.Before(oldest) {
+			oldestId, oldest = orderId, pending.created
+		}
+	}
+	if len(c.pendingOrders) >= maxPendingOrders {
+		delete(c.pendingOrders, oldestId)
+	}
+	c.pendingOrders[order.OrderId] = pendingOrder{order: order, created: now}
+}
+
+func (c *client) SetOrderCostPolicy(policy OrderCostPolicy) {
+	c.ordersMu.Lock()
+	defer c.ordersMu.Unlock()
+	c.orderCostPolicy = policy
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gockOrderCreate(orderId int, cost string) {
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("order.create", nil)).
+		Reply(200).
+		BodyString(fmt.Sprintf(`{"orderId":%d,"changeTxt":"","cost":"%s","currency":"AUD","code":"OK","message":"Order created"}`, orderId, cost))
+}
+
+func gockOrderConfirm(contractId int) {
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("order.confirm", nil)).
+		Reply(200).
+		BodyString(fmt.Sprintf(`{"contractID":[%d],"code":"OK","message":"Order confirmed"}`, contractId))
+}
+
+func TestOrderConfirmBudgetPolicy(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	now := time.Date(2025, time.June, 11, 9, 0, 0, 0, time.UTC)
+	policy := &BudgetPolicy{
+		MaxOrderCost:        Money{Amount: MustParseDecimal("100"), Currency: "AUD"},
+		MaxDailyCost:        Money{Amount: MustParseDecimal("150"), Currency: "AUD"},
+		AllowedProductCodes: []string{"SERVER_VIRTUAL_PERFORMANCE_AU"},
+		Now:                 func() time.Time { return now },
+	}
+	client.SetOrderCostPolicy(policy)
+
+	createAndConfirm := func(orderId int, productCode string, cost string) error {
+		gockOrderCreate(orderId, cost)
+		order, err := client.OrderCreate(context.TODO(), productCode, "456", ProductDetails{})
+		require.NoError(t, err, "OrderCreate error")
+		_, err = client.OrderConfirm(context.TODO(), order.OrderId)
+		return err
+	}
+
+	gockOrderConfirm(1)
+	require.NoError(t, createAndConfirm(1, "SERVER_VIRTUAL_PERFORMANCE_AU", "99.99"), "order within budget")
+	assert.Equal(t, MustParseDecimal("99.99"), policy.DailyCost(), "DailyCost")
+
+	err := createAndConfirm(2, "SERVER_VIRTUAL_PERFORMANCE_AU", "100.01")
+	var refused *OrderRefusedError
+	require.True(t, errors.As(err, &refused), "per-order maximum error should be OrderRefusedError: %v", err)
+	assert.Equal(t, "2", refused.OrderId, "OrderId")
+	assert.Contains(t, refused.Reason, "per-order maximum", "Reason")
+
+	err = createAndConfirm(3, "SERVER_VIRTUAL_PERFORMANCE_AU", "60")
+	assert.True(t, errors.Is(err, ErrOrderRefused), "daily maximum error should be ErrOrderRefused: %v", err)
+
+	err = createAndConfirm(4, "SERVER_DEDICATED_AU", "1")
+	assert.True(t, errors.Is(err, ErrOrderRefused), "product code error should be ErrOrderRefused: %v", err)
+
+	_, err = client.OrderConfirm(context.TODO(), "999")
+	assert.True(t, errors.Is(err, ErrOrderRefused), "unknown order error should be ErrOrderRefused: %v", err)
+
+	now = now.Add(24 * time.Hour)
+	gockOrderConfirm(5)
+	require.NoError(t, createAndConfirm(5, "SERVER_VIRTUAL_PERFORMANCE_AU", "60"), "order the next day")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+}
+
+func TestOrderConfirmPolicyFunc(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	approvalErr := errors.New("not approved by the approval service")
+	var checked PendingOrder
+	client.SetOrderCostPolicy(OrderCostPolicyFunc(func(ctx context.Context, order PendingOrder) error {
+		checked = order
+		return approvalErr
+	}))
+
+	gockOrderCreate(123, "10")
+	order, err := client.OrderCreate(context.TODO(), "SERVER_VIRTUAL_PERFORMANCE_AU", "456", ProductDetails{})
+	require.NoError(t, err, "OrderCreate error")
+
+	_, err = client.OrderConfirm(context.TODO(), order.OrderId)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.True(t, errors.Is(err, ErrOrderRefused), "OrderConfirm error should be ErrOrderRefused: %v", err)
+	assert.True(t, errors.Is(err, approvalErr), "OrderConfirm error should wrap the policy error: %v", err)
+
+	assert.Equal(t, "123", checked.OrderId, "OrderId")
+	assert.Equal(t, "SERVER_VIRTUAL_PERFORMANCE_AU", checked.ProductCode, "ProductCode")
+	assert.Equal(t, "456", checked.CustomerId, "CustomerId")
+	require.NotNil(t, checked.Created, "Created")
+	assert.Equal(t, "10.00 AUD", checked.Created.Cost.String(), "Created.Cost")
+}
+
+func TestOrderConfirmReleasesBudgetOnRejection(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+	client.SetOrderCostPolicy(policy)
+
+	gockOrderCreate(1, "80")
+	order, err := client.OrderCreate(context.TODO(), "SERVER_VIRTUAL_PERFORMANCE_AU", "456", ProductDetails{})
+	require.NoError(t, err, "OrderCreate error")
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("order.confirm", nil)).
+		Reply(200).
+		BodyString(`{"code":"FAULT","message":"Insufficient credit"}`)
+	_, err = client.OrderConfirm(context.TODO(), order.OrderId)
+	assert.Error(t, err, "OrderConfirm error")
+	assert.False(t, errors.Is(err, ErrOrderRefused), "OrderConfirm error should not be ErrOrderRefused: %v", err)
+	assert.True(t, policy.DailyCost().IsZero(), "DailyCost is released: %s", policy.DailyCost())
+
+	gockOrderConfirm(1)
+	_, err = client.OrderConfirm(context.TODO(), order.OrderId)
+	require.NoError(t, err, "OrderConfirm retry error")
+	assert.Equal(t, MustParseDecimal("80"), policy.DailyCost(), "DailyCost")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+}
+
+func TestOrderConfirmKeepsBudgetWithoutContracts(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+	client.SetOrderCostPolicy(policy)
+
+	gockOrderCreate(1, "80")
+	order, err := client.OrderCreate(context.TODO(), "SERVER_VIRTUAL_PERFORMANCE_AU", "456", ProductDetails{})
+	require.NoError(t, err, "OrderCreate error")
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("order.confirm", nil)).
+		Reply(200).
+		BodyString(`{"contractID":[],"code":"OK","message":"Order confirmed"}`)
+	_, err = client.OrderConfirm(context.TODO(), order.OrderId)
+	assert.Error(t, err, "OrderConfirm error")
+	assert.Equal(t, MustParseDecimal("80"), policy.DailyCost(), "DailyCost is still reserved")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+}
+
+func TestBudgetPolicyRecheckReservedOrder(t *testing.T) {
+	order := PendingOrder{
+		OrderId:     "1",
+		ProductCode: "SERVER_VIRTUAL_PERFORMANCE_AU",
+		Created:     &CreatedOrder{Cost: Money{Amount: MustParseDecimal("80"), Currency: "AUD"}},
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+		require.NoError(t, policy.CheckOrder(context.TODO(), order), "CheckOrder error")
+		require.NoError(t, policy.CheckOrder(context.TODO(), order), "CheckOrder retry error")
+		assert.Equal(t, MustParseDecimal("80"), policy.DailyCost(), "DailyCost is reserved once")
+	})
+
+	t.Run("product code no longer allowed", func(t *testing.T) {
+		policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+		require.NoError(t, policy.CheckOrder(context.TODO(), order), "CheckOrder error")
+		policy.AllowedProductCodes = []string{"SERVER_DEDICATED_AU"}
+		err := policy.CheckOrder(context.TODO(), order)
+		assert.True(t, errors.Is(err, ErrOrderRefused), "CheckOrder retry error should be ErrOrderRefused: %v", err)
+		assert.True(t, policy.DailyCost().IsZero(), "DailyCost is released: %s", policy.DailyCost())
+	})
+
+	t.Run("per-order maximum lowered", func(t *testing.T) {
+		policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+		require.NoError(t, policy.CheckOrder(context.TODO(), order), "CheckOrder error")
+		policy.MaxOrderCost = Money{Amount: MustParseDecimal("50"), Currency: "AUD"}
+		err := policy.CheckOrder(context.TODO(), order)
+		assert.True(t, errors.Is(err, ErrOrderRefused), "CheckOrder retry error should be ErrOrderRefused: %v", err)
+		assert.True(t, policy.DailyCost().IsZero(), "DailyCost is released: %s", policy.DailyCost())
+	})
+
+	t.Run("daily maximum lowered", func(t *testing.T) {
+		policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+		require.NoError(t, policy.CheckOrder(context.TODO(), order), "CheckOrder error")
+		policy.MaxDailyCost.Amount = MustParseDecimal("70")
+		err := policy.CheckOrder(context.TODO(), order)
+		assert.True(t, errors.Is(err, ErrOrderRefused), "CheckOrder retry error should be ErrOrderRefused: %v", err)
+		assert.True(t, policy.DailyCost().IsZero(), "DailyCost is released: %s", policy.DailyCost())
+	})
+}
+
+func TestBudgetPolicyConcurrentCheckOrder(t *testing.T) {
+	policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+
+	var wg sync.WaitGroup
+	allowed := make(chan string, 10)
+	for idx := 0; idx < 10; idx++ {
+		wg.Add(1)
+		go func(orderId string) {
+			defer wg.Done()
+			err := policy.CheckOrder(context.TODO(), PendingOrder{
+				OrderId: orderId,
+				Created: &CreatedOrder{OrderId: orderId, Cost: Money{Amount: MustParseDecimal("30"), Currency: "AUD"}},
+			})
+			if err == nil {
+				allowed <- orderId
+			}
+		}(fmt.Sprint(idx))
+	}
+	wg.Wait()
+	close(allowed)
+
+	assert.Len(t, allowed, 3, "orders allowed within the daily maximum")
+	assert.Equal(t, MustParseDecimal("90"), policy.DailyCost(), "DailyCost reserved")
+}
+
+func TestBudgetPolicyOrderConfirmedCurrency(t *testing.T) {
+	policy := &BudgetPolicy{MaxDailyCost: Money{Amount: MustParseDecimal("100"), Currency: "AUD"}}
+
+	policy.OrderConfirmed(context.TODO(), PendingOrder{
+		OrderId: "1",
+		Created: &CreatedOrder{OrderId: "1", Cost: Money{Amount: MustParseDecimal("50"), Currency: "USD"}},
+	})
+	assert.True(t, policy.DailyCost().IsZero(), "cost in another currency is not added: %s", policy.DailyCost())
+
+	policy.OrderConfirmed(context.TODO(), PendingOrder{
+		OrderId: "2",
+		Created: &CreatedOrder{OrderId: "2", Cost: Money{Amount: MustParseDecimal("50"), Currency: "AUD"}},
+	})
+	assert.Equal(t, MustParseDecimal("50"), policy.DailyCost(), "DailyCost")
+}
+
+func TestPendingOrdersEviction(t *testing.T) {
+	c := &client{}
+	c.pendingOrders = map[string]pendingOrder{
+		"expired": {order: PendingOrder{OrderId: "expired"}, created: time.Now().Add(-pendingOrderTTL - time.Minute)},
+	}
+	for idx := 0; idx < maxPendingOrders+1; idx++ {
+		c.addPendingOrder(PendingOrder{OrderId: fmt.Sprint(idx)})
+	}
+	assert.Len(t, c.pendingOrders, maxPendingOrders, "pendingOrders")
+	assert.NotContains(t, c.pendingOrders, "expired", "expired order is forgotten")
+	assert.Contains(t, c.pendingOrders, fmt.Sprint(maxPendingOrders), "newest order is remembered")
+}
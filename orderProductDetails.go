@@ -0,0 +1,321 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProductDetailsFieldError is an invalid field of ProductDetails. Field is the JSON path of the field,
+// e.g. "storage[1].type", so that a form can show the error next to the field.
+type ProductDetailsFieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *ProductDetailsFieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ProductDetailsFieldErrors returns the field errors joined in an error returned by
+// ProductDetails.Validate or ProductDetailsBuilder.Build.
+func ProductDetailsFieldErrors(err error) []*ProductDetailsFieldError {
+	switch e := err.(type) {
+	case *ProductDetailsFieldError:
+		return []*ProductDetailsFieldError{e}
+	case interface{ Unwrap() []error }:
+		var fieldErrs []*ProductDetailsFieldError
+		for _, err := range e.Unwrap() {
+			fieldErrs = append(fieldErrs, ProductDetailsFieldErrors(err)...)
+		}
+		return fieldErrs
+	case interface{ Unwrap() error }:
+		return ProductDetailsFieldErrors(e.Unwrap())
+	}
+	return nil
+}
+
+func newProductDetailsFieldError(field string, format string, args ...any) error {
+	return &ProductDetailsFieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks the product details of a virtual server for errors that order.create would otherwise
+// reject. The returned error joins a *ProductDetailsFieldError per invalid field.
+func (pd ProductDetails) Validate() error {
+	var errs []error
+	if pd.Hostname != "" && !isValidHostname(pd.Hostname) {
+		errs = append(errs, newProductDetailsFieldError("hostname", "%q is not a valid host name", pd.Hostname))
+	}
+	if pd.DataCenterId != "" {
+		if id, err := strconv.Atoi(pd.DataCenterId); err != nil || id <= 0 {
+			errs = append(errs, newProductDetailsFieldError("dcId", "%q is not a valid data center ID", pd.DataCenterId))
+		}
+	}
+	for idx, credential := range pd.Credentials {
+		if credential.Username == "" {
+			errs = append(errs, newProductDetailsFieldError(fmt.Sprintf("credentials[%d].username", idx), "is required"))
+		}
+	}
+	if pd.CpuCount < 1 {
+		errs = append(errs, newProductDetailsFieldError("cpu", "%d must be at least 1", pd.CpuCount))
+	}
+	if pd.MemoryGB < 1 {
+		errs = append(errs, newProductDetailsFieldError("memoryGB", "%d must be at least 1", pd.MemoryGB))
+	}
+	if pd.TrafficGB < 0 {
+		errs = append(errs, newProductDetailsFieldError("trafficGB", "%d must not be negative", pd.TrafficGB))
+	}
+	for idx, storage := range pd.Storage {
+		errs = append(errs, storage.validate(fmt.Sprintf("storage[%d]", idx))...)
+	}
+	for idx, policy := range pd.FirewallPolicies {
+		errs = append(errs, policy.validate(fmt.Sprintf("firewallPolicies[%d]", idx))...)
+	}
+	for idx, nic := range pd.Nics {
+		errs = append(errs, nic.validate(fmt.Sprintf("nics[%d]", idx))...)
+	}
+	if pd.Timezone != "" {
+		if _, err := time.LoadLocation(pd.Timezone); err != nil {
+			errs = append(errs, newProductDetailsFieldError("timezone", "%q is not an IANA time zone", pd.Timezone))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateForProduct checks the product details like Validate, and that productCode is a virtual server
+// product code, see GetVirtualServerProductCode, whose server class is one of ServerClasses.
+func (pd ProductDetails) ValidateForProduct(productCode string) error {
+	var errs []error
+	serverClass, _, ok := parseVirtualServerProductCode(productCode)
+	if !ok {
+		errs = append(errs, newProductDetailsFieldError("productCode", "%q is not a virtual server product code", productCode))
+	} else if !slices.Contains(ServerClasses, serverClass) {
+		errs = append(errs, newProductDetailsFieldError("serverClass", "%q must be one of %v", serverClass, ServerClasses))
+	}
+	if err := pd.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// parseVirtualServerProductCode splits a product code returned by GetVirtualServerProductCode.
+func parseVirtualServerProductCode(productCode string) (serverClass string, country string, ok bool) {
+	rest, ok := strings.CutPrefix(productCode, "SERVER_VIRTUAL_")
+	if !ok {
+		return "", "", false
+	}
+	idx := strings.LastIndex(rest, "_")
+	if idx < 0 {
+		return "", "", false
+	}
+	return rest[:idx], rest[idx+1:], true
+}
+
+func (s Storage) validate(field string) []error {
+	var errs []error
+	if s.SizeGB < 1 {
+		errs = append(errs, newProductDetailsFieldError(field+".sizeGB", "%d must be at least 1", s.SizeGB))
+	}
+	if !slices.Contains(StorageTypes, s.StorageType) {
+		errs = append(errs, newProductDetailsFieldError(field+".type", "%q must be one of %v", s.StorageType, StorageTypes))
+	}
+	return errs
+}
+
+func (fp FirewallPolicy) validate(field string) []error {
+	var errs []error
+	if !slices.Contains(FirewallPolicyDirections, fp.Direction) {
+		errs = append(errs, newProductDetailsFieldError(field+".direction", "%q must be one of %v", fp.Direction, FirewallPolicyDirections))
+	}
+	if !slices.Contains(FirewallPolicyTypes, fp.Policy) {
+		errs = append(errs, newProductDetailsFieldError(field+".policy", "%q must be one of %v", fp.Policy, FirewallPolicyTypes))
+	}
+	for _, ip := range []struct {
+		name  string
+		value string
+	}{
+		{"ipAddressFrom", fp.IpAddressFrom},
+		{"ipAddressTo", fp.IpAddressTo},
+	} {
+		if ip.value == "" {
+			continue
+		}
+		if _, err := netip.ParseAddr(ip.value); err == nil {
+			continue
+		}
+		if _, err := netip.ParsePrefix(ip.value); err != nil {
+			errs = append(errs, newProductDetailsFieldError(field+"."+ip.name, "%q is not an IP address or prefix", ip.value))
+		}
+	}
+	portFrom, portFromErr := parseFirewallPort(fp.PortFrom)
+	if portFromErr != nil {
+		errs = append(errs, newProductDetailsFieldError(field+".portFrom", "%s", portFromErr))
+	}
+	portTo, portToErr := parseFirewallPort(fp.PortTo)
+	if portToErr != nil {
+		errs = append(errs, newProductDetailsFieldError(field+".portTo", "%s", portToErr))
+	}
+	if portFromErr == nil && portToErr == nil && portFrom != 0 && portTo != 0 && portFrom > portTo {
+		errs = append(errs, newProductDetailsFieldError(field+".portFrom", "%d must not be greater than portTo %d", portFrom, portTo))
+	}
+	return errs
+}
+
+// parseFirewallPort parses a firewall policy port, returning 0 for no port.
+func parseFirewallPort(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a port between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+func (n Nic) validate(field string) []error {
+	var errs []error
+	if n.Vlan < 0 || n.Vlan > 4094 {
+		errs = append(errs, newProductDetailsFieldError(field+".vlan", "%d must be between 0 and 4094", n.Vlan))
+	}
+	if n.Speed < 1 {
+		errs = append(errs, newProductDetailsFieldError(field+".speed", "%d must be at least 1 Mbit", n.Speed))
+	}
+	for _, count := range []struct {
+		name  string
+		value int
+	}{
+		{"ipv4", n.IPV4},
+		{"poolIPv4", n.PoolIPv4},
+		{"ipv6", n.IPV6},
+		{"poolIPv6", n.PoolIPv6},
+	} {
+		if count.value < 0 {
+			errs = append(errs, newProductDetailsFieldError(field+"."+count.name, "%d must not be negative", count.value))
+		}
+	}
+	return errs
+}
+
+// ProductDetailsBuilder builds the product code and ProductDetails of a virtual server order.
+type ProductDetailsBuilder struct {
+	serverClass string
+	country     string
+	details     ProductDetails
+	errs        []error
+}
+
+func NewProductDetailsBuilder() *ProductDetailsBuilder {
+	return &ProductDetailsBuilder{}
+}
+
+// ServerClass sets the server class, one of ServerClasses, and the country code used by ProductCode.
+func (b *ProductDetailsBuilder) ServerClass(serverClass string, country string) *ProductDetailsBuilder {
+	if serverClass == "" {
+		b.errs = append(b.errs, newProductDetailsFieldError("serverClass", "is required"))
+	}
+	if country == "" {
+		b.errs = append(b.errs, newProductDetailsFieldError("country", "is required"))
+	}
+	b.serverClass = serverClass
+	b.country = country
+	return b
+}
+
+// ProductCode returns the product code to pass to OrderCreate with the built ProductDetails.
+func (b *ProductDetailsBuilder) ProductCode() string {
+	return GetVirtualServerProductCode(b.serverClass, b.country)
+}
+
+func (b *ProductDetailsBuilder) Hostname(hostname string) *ProductDetailsBuilder {
+	b.details.Hostname = hostname
+	return b
+}
+
+func (b *ProductDetailsBuilder) DataCenter(dataCenterId string) *ProductDetailsBuilder {
+	b.details.DataCenterId = dataCenterId
+	return b
+}
+
+func (b *ProductDetailsBuilder) Location(location string) *ProductDetailsBuilder {
+	b.details.Location = location
+	return b
+}
+
+func (b *ProductDetailsBuilder) Timezone(timezone string) *ProductDetailsBuilder {
+	b.details.Timezone = timezone
+	return b
+}
+
+func (b *ProductDetailsBuilder) CPU(count int) *ProductDetailsBuilder {
+	b.details.CpuCount = count
+	return b
+}
+
+func (b *ProductDetailsBuilder) MemoryGB(memoryGB int) *ProductDetailsBuilder {
+	b.details.MemoryGB = memoryGB
+	return b
+}
+
+func (b *ProductDetailsBuilder) TrafficGB(trafficGB int) *ProductDetailsBuilder {
+	b.details.TrafficGB = trafficGB
+	return b
+}
+
+func (b *ProductDetailsBuilder) HostGroup(hostGroupId int) *ProductDetailsBuilder {
+	b.details.HostGroupID = &hostGroupId
+	return b
+}
+
+func (b *ProductDetailsBuilder) Install(install Install) *ProductDetailsBuilder {
+	b.details.Install = install
+	return b
+}
+
+func (b *ProductDetailsBuilder) AddCredential(username string, password string) *ProductDetailsBuilder {
+	b.details.Credentials = append(b.details.Credentials, Credential{Username: username, Password: password})
+	return b
+}
+
+// AddStorage adds a disk, ordered after the disks already added.
+func (b *ProductDetailsBuilder) AddStorage(name string, sizeGB int, storageType string) *ProductDetailsBuilder {
+	b.details.Storage = append(b.details.Storage, Storage{
+		Name:        name,
+		SizeGB:      sizeGB,
+		StorageType: storageType,
+		SortOrder:   len(b.details.Storage) + 1,
+	})
+	return b
+}
+
+func (b *ProductDetailsBuilder) AddFirewallPolicy(policy FirewallPolicy) *ProductDetailsBuilder {
+	b.details.FirewallPolicies = append(b.details.FirewallPolicies, policy)
+	return b
+}
+
+func (b *ProductDetailsBuilder) AddNic(nic Nic) *ProductDetailsBuilder {
+	b.details.Nics = append(b.details.Nics, nic)
+	return b
+}
+
+// Build returns the ProductDetails, or the errors of ServerClass joined with those of ValidateForProduct.
+func (b *ProductDetailsBuilder) Build() (ProductDetails, error) {
+	errs := slices.Clone(b.errs)
+	if b.serverClass == "" && b.country == "" {
+		errs = append(errs, newProductDetailsFieldError("serverClass", "is required"))
+	}
+	var err error
+	if b.serverClass != "" {
+		err = b.details.ValidateForProduct(b.ProductCode())
+	} else {
+		err = b.details.Validate()
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return b.details, errors.Join(errs...)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductDetailsBuilder(t *testing.T) {
+	builder := NewProductDetailsBuilder().
+		ServerClass(ServerClassPerformance, "AU").
+		Hostname("web1.example.com").
+		DataCenter("1").
+		Timezone("Australia/Sydney").
+		CPU(2).
+		MemoryGB(4).
+		Install(Install{OperatingSystem: "UBUNTU22.04_64"}).
+		AddCredential("root", "secret").
+		AddStorage("root", 40, StorageTypeSSD).
+		AddStorage("data", 200, StorageTypeMagnetic).
+		AddFirewallPolicy(FirewallPolicy{
+			Direction: FirewallPolicyDirectionInbound,
+			Policy:    FirewallPolicyTypeAllow,
+			Protocol:  "TCP",
+			PortFrom:  "80",
+			PortTo:    "443",
+		}).
+		AddNic(Nic{Name: "public", Speed: 1000, IPV4: 1, IPV6: 16})
+
+	details, err := builder.Build()
+	require.NoError(t, err, "Build error")
+
+	assert.Equal(t, "SERVER_VIRTUAL_PERFORMANCE_AU", builder.ProductCode(), "ProductCode")
+	assert.Equal(t, "web1.example.com", details.Hostname, "Hostname")
+	assert.Equal(t, 2, details.CpuCount, "CpuCount")
+	require.Len(t, details.Storage, 2, "Storage")
+	assert.Equal(t, 1, details.Storage[0].SortOrder, "Storage[0].SortOrder")
+	assert.Equal(t, 2, details.Storage[1].SortOrder, "Storage[1].SortOrder")
+	assert.NoError(t, details.Validate(), "Validate")
+}
+
+func TestProductDetailsBuilderErrors(t *testing.T) {
+	_, err := NewProductDetailsBuilder().
+		ServerClass("GOLD", "AU").
+		Hostname("-web1").
+		Timezone("Not/AZone").
+		CPU(0).
+		MemoryGB(2).
+		AddStorage("root", 0, "NVME").
+		AddFirewallPolicy(FirewallPolicy{
+			Direction:     "SIDEWAYS",
+			Policy:        FirewallPolicyTypeDeny,
+			IpAddressFrom: "10.0.0.0/33",
+			PortFrom:      "443",
+			PortTo:        "80",
+		}).
+		AddNic(Nic{Speed: 0, IPV4: -1}).
+		Build()
+	require.Error(t, err, "Build error")
+
+	var fields []string
+	for _, fieldErr := range ProductDetailsFieldErrors(err) {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{
+		"serverClass",
+		"hostname",
+		"cpu",
+		"storage[0].sizeGB",
+		"storage[0].type",
+		"firewallPolicies[0].direction",
+		"firewallPolicies[0].ipAddressFrom",
+		"firewallPolicies[0].portFrom",
+		"nics[0].speed",
+		"nics[0].ipv4",
+		"timezone",
+	}, fields, "field errors")
+	assert.Contains(t, err.Error(), `timezone: "Not/AZone" is not an IANA time zone`, "Error")
+
+	_, err = NewProductDetailsBuilder().CPU(1).MemoryGB(1).Build()
+	require.Len(t, ProductDetailsFieldErrors(err), 1, "missing server class")
+	assert.Equal(t, "serverClass", ProductDetailsFieldErrors(err)[0].Field, "missing server class")
+}
+
+func TestProductDetailsValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		details ProductDetails
+		field   string
+	}{
+		"valid": {
+			details: ProductDetails{CpuCount: 1, MemoryGB: 1},
+		},
+		"private nic": {
+			details: ProductDetails{CpuCount: 1, MemoryGB: 1, Nics: []Nic{{Speed: 25000}}},
+		},
+		"data center": {
+			details: ProductDetails{CpuCount: 1, MemoryGB: 1, DataCenterId: "SYD"},
+			field:   "dcId",
+		},
+		"memory": {
+			details: ProductDetails{CpuCount: 1},
+			field:   "memoryGB",
+		},
+		"credential": {
+			details: ProductDetails{CpuCount: 1, MemoryGB: 1, Credentials: []Credential{{Password: "secret"}}},
+			field:   "credentials[0].username",
+		},
+		"firewall policy type": {
+			details: ProductDetails{CpuCount: 1, MemoryGB: 1, FirewallPolicies: []FirewallPolicy{{Direction: FirewallPolicyDirectionAny, Policy: "DROP"}}},
+			field:   "firewallPolicies[0].policy",
+		},
+		"firewall port": {
+			details: ProductDetails{CpuCount: 1, MemoryGB: 1, FirewallPolicies: []FirewallPolicy{{Direction: FirewallPolicyDirectionAny, Policy: FirewallPolicyTypeAllow, PortTo: "70000"}}},
+			field:   "firewallPolicies[0].portTo",
+		},
+		"nic vlan": {
+			details: ProductDetails{CpuCount: 1, MemoryGB: 1, Nics: []Nic{{Vlan: 5000, Speed: 1000}}},
+			field:   "nics[0].vlan",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.details.Validate()
+			if tc.field == "" {
+				assert.NoError(t, err, "Validate")
+				return
+			}
+			fieldErrs := ProductDetailsFieldErrors(err)
+			require.Len(t, fieldErrs, 1, "field errors: %v", err)
+			assert.Equal(t, tc.field, fieldErrs[0].Field, "Field")
+		})
+	}
+}
+
+func TestProductDetailsValidateForProduct(t *testing.T) {
+	details := ProductDetails{CpuCount: 1, MemoryGB: 1}
+	assert.NoError(t, details.ValidateForProduct(GetVirtualServerProductCode(ServerClassBudget, "AU")), "valid")
+
+	for productCode, field := range map[string]string{
+		GetVirtualServerProductCode("GOLD", "AU"): "serverClass",
+		"SERVER_DEDICATED_AU":                     "productCode",
+	} {
+		fieldErrs := ProductDetailsFieldErrors(details.ValidateForProduct(productCode))
+		require.Len(t, fieldErrs, 1, "field errors of %s", productCode)
+		assert.Equal(t, field, fieldErrs[0].Field, "Field of %s", productCode)
+	}
+}
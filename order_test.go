@@ -43,7 +43,7 @@ func TestOrderCreate(t *testing.T) {
 		},
 	}
 
-	const responseBody = `{"orderId":123,"changeTxt":"Add NEW SUPPORT: SUPPORTSTD ($0.00)\nAdd NEW IPV6: 16 ($0.00)\n","code":"OK","message":"Order created"}`
+	const responseBody = `{"orderId":123,"changeTxt":"Add NEW SUPPORT: SUPPORTSTD ($0.00)\nAdd NEW IPV6: 16 ($0.00)\n","cost":"49.50","currency":"AUD","netCost":45.00,"retailCost":"55.00","retailNetCost":50,"code":"OK","message":"Order created"}`
 
 	client := getTestClient(t)
 
@@ -58,6 +58,12 @@ func TestOrderCreate(t *testing.T) {
 
 	assert.Equal(t, "123", order.OrderId, "OrderId")
 	assert.Contains(t, order.ChangeText, "Add NEW", "ChangeText")
+	assert.Equal(t, Money{Amount: MustParseDecimal("49.50"), Currency: "AUD"}, order.Cost, "Cost")
+	assert.Equal(t, "45.00 AUD", order.NetCost.String(), "NetCost")
+	assert.Equal(t, "55.00 AUD", order.RetailCost.String(), "RetailCost")
+	assert.Equal(t, "50.00 AUD", order.RetailNetCost.String(), "RetailNetCost")
+	require.Len(t, order.Changes, 2, "Changes")
+	assert.Equal(t, "IPV6", order.Changes[1].Item, "Changes[1].Item")
 
 	assert.True(t, gock.IsDone(), "gock.IsDone")
 }
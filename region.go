@@ -1,5 +1,196 @@
 package api
 
-import "github.com/rackcorpcloud/rackcorp-api-go/apiv2"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rackcorpcloud/rackcorp-api-go/apiv2"
+	"github.com/rackcorpcloud/rackcorp-api-go/internal"
+)
 
 type RegionID = apiv2.RegionID
+
+type DataCenterID int
+
+func (id *DataCenterID) UnmarshalJSON(data []byte) error {
+	return internal.UnmarshalJSONInt(id, data)
+}
+
+type DataCenter struct {
+	ID   DataCenterID `json:"id"`
+	Name string       `json:"name"`
+}
+
+type Region struct {
+	ID          RegionID     `json:"id"`
+	Name        string       `json:"name"`
+	Country     string       `json:"country"`
+	DataCenters []DataCenter `json:"datacenters"`
+}
+
+// defaultRegionCacheTTL is how long the region catalogue is cached by the client.
+const defaultRegionCacheTTL = time.Hour
+
+// regionCache holds the region catalogue shared by every RegionClient of a client.
+type regionCache struct {
+	mu      sync.Mutex
+	regions []Region
+	fetched time.Time
+}
+
+type regionGetAllRequest struct {
+	legacyRequest
+}
+
+type regionGetAllResponse struct {
+	response
+	Regions []Region `json:"regions"`
+}
+
+type RegionClient interface {
+	// GetAll returns a copy of the region catalogue, which is cached by the client for an hour.
+	GetAll(ctx context.Context) ([]Region, error)
+	Get(ctx context.Context, id RegionID) (*Region, error)
+	// GetByName finds a region by name, ignoring case.
+	GetByName(ctx context.Context, name string) (*Region, error)
+	// IDsByName returns the IDs of the named regions in order, e.g. for the Regions of a GLB load balancer.
+	IDsByName(ctx context.Context, names ...string) ([]RegionID, error)
+	// ValidateIDs checks that every ID is in the region catalogue.
+	ValidateIDs(ctx context.Context, ids []RegionID) error
+	// InvalidateCache discards the cached region catalogue so the next call gets it again.
+	InvalidateCache()
+}
+
+type regionClient struct {
+	c *client
+}
+
+var _ RegionClient = (*regionClient)(nil)
+
+func (rc *regionClient) GetAll(ctx context.Context) ([]Region, error) {
+	regions, err := rc.getAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	regions = slices.Clone(regions)
+	for idx, region := range regions {
+		regions[idx] = region.clone()
+	}
+	return regions, nil
+}
+
+// clone returns a copy of the region that does not share DataCenters with the cache.
+func (r Region) clone() Region {
+	r.DataCenters = slices.Clone(r.DataCenters)
+	return r
+}
+
+// getAll returns the cached region catalogue, which must not be modified. The catalogue is fetched without
+// holding the cache lock, so that a slow refresh does not block readers of a catalogue that is still fresh.
+func (rc *regionClient) getAll(ctx context.Context) ([]Region, error) {
+	cache := &rc.c.regionCache
+	cache.mu.Lock()
+	regions, fetched := cache.regions, cache.fetched
+	cache.mu.Unlock()
+
+	if regions != nil && time.Since(fetched) < defaultRegionCacheTTL {
+		return regions, nil
+	}
+
+	req := regionGetAllRequest{
+		legacyRequest: legacyRequest{
+			Command: "region.getall",
+		},
+	}
+	var resp regionGetAllResponse
+	err := rc.c.httpLegacyJson(ctx, &req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsOK() {
+		return nil, newApiError(resp.response, nil)
+	}
+
+	regions = resp.Regions
+	if regions == nil {
+		regions = []Region{}
+	}
+	cache.mu.Lock()
+	cache.regions = regions
+	cache.fetched = time.Now()
+	cache.mu.Unlock()
+	return regions, nil
+}
+
+func (rc *regionClient) Get(ctx context.Context, id RegionID) (*Region, error) {
+	regions, err := rc.getAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, region := range regions {
+		if region.ID == id {
+			region = region.clone()
+			return &region, nil
+		}
+	}
+	return nil, fmt.Errorf("region %d does not exist", id)
+}
+
+func (rc *regionClient) GetByName(ctx context.Context, name string) (*Region, error) {
+	regions, err := rc.getAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, region := range regions {
+		if strings.EqualFold(region.Name, name) {
+			region = region.clone()
+			return &region, nil
+		}
+	}
+	return nil, fmt.Errorf("region %q does not exist", name)
+}
+
+func (rc *regionClient) IDsByName(ctx context.Context, names ...string) ([]RegionID, error) {
+	ids := make([]RegionID, len(names))
+	for idx, name := range names {
+		region, err := rc.GetByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		ids[idx] = region.ID
+	}
+	return ids, nil
+}
+
+func (rc *regionClient) ValidateIDs(ctx context.Context, ids []RegionID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	regions, err := rc.getAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get regions: %w", err)
+	}
+	known := make(map[RegionID]bool, len(regions))
+	for _, region := range regions {
+		known[region.ID] = true
+	}
+	var errs []error
+	for _, id := range ids {
+		if !known[id] {
+			errs = append(errs, fmt.Errorf("region %d does not exist", id))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (rc *regionClient) InvalidateCache() {
+	cache := &rc.c.regionCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.regions = nil
+}
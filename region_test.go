@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionGetAllIsCached(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	gockRegionGetAll(t)
+
+	regions, err := client.Region().GetAll(context.TODO())
+	require.NoError(t, err, "GetAll error")
+	require.Len(t, regions, 3, "regions")
+	assert.Equal(t, Region{
+		ID:      1,
+		Name:    "Sydney",
+		Country: "AU",
+		DataCenters: []DataCenter{
+			{ID: 2, Name: "SYD1"},
+			{ID: 7, Name: "SYD2"},
+		},
+	}, regions[0], "regions[0]")
+
+	regions[0].Name = "Modified"
+	regions[0].DataCenters[0].Name = "Modified"
+	regions, err = client.Region().GetAll(context.TODO())
+	require.NoError(t, err, "GetAll error")
+	assert.Equal(t, "Sydney", regions[0].Name, "cached region is not modified through GetAll")
+	assert.Equal(t, "SYD1", regions[0].DataCenters[0].Name, "cached data center is not modified through GetAll")
+
+	region, err := client.Region().Get(context.TODO(), 1)
+	require.NoError(t, err, "Get error")
+	region.DataCenters[0].Name = "Modified"
+	region, err = client.Region().GetByName(context.TODO(), "sydney")
+	require.NoError(t, err, "GetByName error")
+	assert.Equal(t, "SYD1", region.DataCenters[0].Name, "cached data center is not modified through Get")
+	region.DataCenters[0].Name = "Modified"
+	regions, err = client.Region().GetAll(context.TODO())
+	require.NoError(t, err, "GetAll error")
+	assert.Equal(t, "SYD1", regions[0].DataCenters[0].Name, "cached data center is not modified through GetByName")
+
+	region, err = client.Region().GetByName(context.TODO(), "singapore")
+	require.NoError(t, err, "GetByName error")
+	assert.Equal(t, RegionID(3), region.ID, "GetByName ID")
+
+	ids, err := client.Region().IDsByName(context.TODO(), "Sydney", "Singapore")
+	require.NoError(t, err, "IDsByName error")
+	assert.Equal(t, []RegionID{1, 3}, ids, "IDsByName")
+
+	_, err = client.Region().IDsByName(context.TODO(), "Sydney", "Atlantis")
+	assert.Error(t, err, "IDsByName unknown region")
+
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+
+	client.Region().InvalidateCache()
+	gockRegionGetAll(t)
+	_, err = client.Region().Get(context.TODO(), 2)
+	require.NoError(t, err, "Get error")
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone after InvalidateCache")
+}
+
+func TestLoadBalancerCreateUnknownRegion(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	gockRegionGetAll(t)
+
+	_, err := client.LoadBalancer().Create(context.TODO(), LoadBalancer{
+		Name:    "global",
+		Type:    LoadBalancerTypeGLB,
+		Regions: []RegionID{1, 99},
+	})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.EqualError(t, err, "region 99 does not exist", "Create error")
+}
+
+func TestLoadBalancerUpdateValidatesAddedRegions(t *testing.T) {
+	defer gock.OffAll()
+
+	client := getTestClient(t)
+	responseBody := getTestDataString(t, "loadbalancer.get.responseBody.json")
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("loadbalancer.get", nil)).
+		Reply(200).
+		BodyString(responseBody)
+	gockRegionGetAll(t)
+
+	lb, err := client.LoadBalancer().Get(context.TODO(), 1234)
+	require.NoError(t, err, "Get error")
+
+	lb.Regions = append(lb.Regions, 99)
+	_, err = client.LoadBalancer().Update(context.TODO(), *lb)
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	assert.EqualError(t, err, "region 99 does not exist", "Update error")
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"strconv"
+	"time"
 )
 
 type createdTransaction struct {
@@ -100,21 +102,62 @@ type TransactionStartupData struct {
 	CloudInit TransactionStartupCloudInit `json:"cloudInit"`
 }
 
+// TransactionApprover decides whether a transaction that requires confirmation should proceed.
+// It is shown the ConfirmationText returned when the transaction was created, e.g. a cost or data loss warning.
+type TransactionApprover func(ctx context.Context, transaction *Transaction) (bool, error)
+
+var ErrTransactionNotApproved = errors.New("transaction was not approved")
+
+// TransactionDataEncoder is implemented by transaction payloads that are not sent as JSON.
+type TransactionDataEncoder interface {
+	EncodeTransactionData() (string, error)
+}
+
+// TransactionOpenVNCData is the payload for TransactionTypeOpenVNC.
+type TransactionOpenVNCData struct {
+	AllowedIP netip.Addr
+}
+
+var _ TransactionDataEncoder = TransactionOpenVNCData{}
+
+func (d TransactionOpenVNCData) EncodeTransactionData() (string, error) {
+	if !d.AllowedIP.IsValid() {
+		return "", errors.New("field AllowedIP of TransactionOpenVNCData is required")
+	}
+	return d.AllowedIP.String(), nil
+}
+
+// TransactionCancelData is the payload for TransactionTypeCancel, the transaction to cancel.
+type TransactionCancelData struct {
+	TransactionId string
+}
+
+var _ TransactionDataEncoder = TransactionCancelData{}
+
+func (d TransactionCancelData) EncodeTransactionData() (string, error) {
+	if d.TransactionId == "" {
+		return "", errors.New("field TransactionId of TransactionCancelData is required")
+	}
+	return d.TransactionId, nil
+}
+
 const (
-	TransactionObjectTypeDevice = "DEVICE"
+	TransactionObjectTypeDevice       = "DEVICE"
+	TransactionObjectTypeLoadBalancer = "LOADBALANCER"
 
 	TransactionStatusCommenced = "COMMENCED"
 	TransactionStatusCompleted = "COMPLETED"
 	TransactionStatusPending   = "PENDING"
 
-	TransactionTypeCancel        = "CANCEL"
+	TransactionTypeCancel        = "CANCEL" // data parameter contains the transaction to cancel, see TransactionCancelData
 	TransactionTypeCloseVNC      = "CLOSEVNC"
 	TransactionTypeForceShutdown = "FORCESHUTDOWN"
-	TransactionTypeOpenVNC       = "OPENVNC" // data parameter contains public IP that allows VNC
+	TransactionTypeOpenVNC       = "OPENVNC" // data parameter contains public IP that allows VNC, see TransactionOpenVNCData
+	TransactionTypePurge         = "PURGE"   // data parameter selects the CDN content to purge, see LoadBalancerPurge
 	TransactionTypeRefreshConfig = "REFRESHCONFIG"
 	TransactionTypeSafeShutdown  = "SAFESHUTDOWN"
 	TransactionTypeShutdown      = "SHUTDOWN"
-	TransactionTypeStartup       = "STARTUP"
+	TransactionTypeStartup       = "STARTUP" // data parameter contains the deploy and cloud-init settings, see TransactionStartupData
 )
 
 func (t *createdTransaction) ToTransaction() *Transaction {
@@ -142,18 +185,13 @@ func (t *existingTransaction) ToTransaction() *Transaction {
 }
 
 func (c *client) TransactionDeviceStartup(ctx context.Context, deviceId string, data TransactionStartupData) (*Transaction, error) {
-	var encodedData, err = json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to JSON encode transaction startup data: %w", err)
-	}
-
-	return c.transactionCreateInternal(
+	return c.TransactionCreateWithData(
 		ctx,
 		TransactionTypeStartup,
 		TransactionObjectTypeDevice,
 		deviceId,
 		true,
-		string(encodedData),
+		data,
 	)
 }
 
@@ -168,6 +206,41 @@ func (c *client) TransactionCreate(ctx context.Context, transactionType string,
 	)
 }
 
+// TransactionCreateWithData creates a transaction with a data parameter.
+// A string is sent as-is, a TransactionDataEncoder encodes itself and anything else is JSON encoded.
+func (c *client) TransactionCreateWithData(ctx context.Context, transactionType string, objectType string, objectId string, confirm bool, data any) (*Transaction, error) {
+	encodedData, err := encodeTransactionData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.transactionCreateInternal(
+		ctx,
+		transactionType,
+		objectType,
+		objectId,
+		confirm,
+		encodedData,
+	)
+}
+
+func encodeTransactionData(data any) (string, error) {
+	switch d := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return d, nil
+	case TransactionDataEncoder:
+		return d.EncodeTransactionData()
+	}
+
+	encodedData, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to JSON encode transaction data: %w", err)
+	}
+	return string(encodedData), nil
+}
+
 func (c *client) transactionCreateInternal(ctx context.Context, transactionType string, objectType string, objectId string, confirm bool, data string) (*Transaction, error) {
 	if transactionType == "" {
 		return nil, errors.New("transactionType parameter is required")
@@ -253,3 +326,143 @@ func (c *client) TransactionGetAll(ctx context.Context, filter TransactionFilter
 
 	return transactions, resp.Matches, nil
 }
+
+// TransactionWait polls the transaction until it is no longer pending or commenced.
+// An error is returned if the transaction finishes in any status other than COMPLETED.
+func (c *client) TransactionWait(ctx context.Context, transactionId string) (*Transaction, error) {
+	if transactionId == "" {
+		return nil, errors.New("transactionId parameter is required")
+	}
+
+	ticker := time.NewTicker(c.transactionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		transaction, err := c.TransactionGet(ctx, transactionId)
+		if err != nil {
+			return nil, err
+		}
+
+		switch transaction.Status {
+		case TransactionStatusPending, TransactionStatusCommenced:
+			c.debugLog(fmt.Sprintf("Rackcorp transaction '%s' is %s", transactionId, transaction.Status))
+		case TransactionStatusCompleted:
+			return transaction, nil
+		default:
+			return transaction, fmt.Errorf("transaction id '%s' finished with status %s: %s", transactionId, transaction.Status, transaction.StatusInfo)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed waiting for transaction id '%s': %w", transactionId, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// TransactionConfirm confirms a transaction that was created with confirm set to false. The API cannot
+// confirm a transaction by ID, so it is created again with the same type, object and data and confirmation
+// set, and the original is then cancelled. The returned transaction is the new one, with a different ID.
+// If the new transaction cannot be created the original is left pending, so that the confirmation can be
+// retried. If the original cannot be cancelled the new transaction is returned with the error.
+func (c *client) TransactionConfirm(ctx context.Context, transactionId string) (*Transaction, error) {
+	if transactionId == "" {
+		return nil, errors.New("transactionId parameter is required")
+	}
+
+	transaction, err := c.TransactionGet(ctx, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm transaction id '%s': %w", transactionId, err)
+	}
+
+	return c.transactionConfirm(ctx, transaction)
+}
+
+func (c *client) transactionConfirm(ctx context.Context, transaction *Transaction) (*Transaction, error) {
+	confirmed, err := c.transactionCreateInternal(
+		ctx,
+		transaction.Type,
+		transaction.ObjectType,
+		transaction.ObjectId,
+		true,
+		transaction.Data,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm transaction id '%s', which is still pending: %w", transaction.TransactionId, err)
+	}
+
+	// The original must be cancelled even if ctx was cancelled meanwhile, as it duplicates the confirmed one
+	if err := c.transactionCancel(context.WithoutCancel(ctx), transaction); err != nil {
+		return confirmed, fmt.Errorf("confirmed transaction id '%s' as transaction id '%s' but the original is still pending: %w",
+			transaction.TransactionId, confirmed.TransactionId, err)
+	}
+	return confirmed, nil
+}
+
+// TransactionCancel cancels a pending transaction that has not been confirmed, by creating
+// a CANCEL transaction for it on the same object.
+func (c *client) TransactionCancel(ctx context.Context, transactionId string) error {
+	if transactionId == "" {
+		return errors.New("transactionId parameter is required")
+	}
+
+	transaction, err := c.TransactionGet(ctx, transactionId)
+	if err != nil {
+		return fmt.Errorf("failed to cancel transaction id '%s': %w", transactionId, err)
+	}
+
+	return c.transactionCancel(ctx, transaction)
+}
+
+func (c *client) transactionCancel(ctx context.Context, transaction *Transaction) error {
+	_, err := c.TransactionCreateWithData(
+		ctx,
+		TransactionTypeCancel,
+		transaction.ObjectType,
+		transaction.ObjectId,
+		true,
+		TransactionCancelData{TransactionId: transaction.TransactionId},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel transaction id '%s': %w", transaction.TransactionId, err)
+	}
+	return nil
+}
+
+// TransactionCreateWithApproval creates an unconfirmed transaction and, if Rackcorp requires confirmation,
+// asks approve whether to confirm it. A rejected transaction is cancelled and ErrTransactionNotApproved is returned.
+func (c *client) TransactionCreateWithApproval(ctx context.Context, transactionType string, objectType string, objectId string, data any, approve TransactionApprover) (*Transaction, error) {
+	if approve == nil {
+		return nil, errors.New("approve parameter is required")
+	}
+
+	transaction, err := c.TransactionCreateWithData(ctx, transactionType, objectType, objectId, false, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !transaction.ConfirmationRequired {
+		return transaction, nil
+	}
+
+	approved, err := approve(ctx, transaction)
+	if err == nil && approved {
+		confirmed, err := c.transactionConfirm(ctx, transaction)
+		if err != nil && confirmed == nil {
+			// nothing will retry the confirmation, so the pending transaction is cancelled
+			return nil, errors.Join(err, c.transactionCancel(context.WithoutCancel(ctx), transaction))
+		}
+		return confirmed, err
+	}
+
+	// The approver may have failed because ctx was cancelled, the cancel must still be sent
+	c.debugLog(fmt.Sprintf("Rackcorp transaction '%s' was not approved, cancelling", transaction.TransactionId))
+	cancelErr := c.transactionCancel(context.WithoutCancel(ctx), transaction)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to approve transaction id '%s': %w", transaction.TransactionId, err), cancelErr)
+	}
+	if cancelErr != nil {
+		return nil, errors.Join(ErrTransactionNotApproved, cancelErr)
+	}
+	return nil, ErrTransactionNotApproved
+}
@@ -2,6 +2,9 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/netip"
 	"testing"
 
 	"github.com/h2non/gock"
@@ -39,3 +42,280 @@ func TestTransactionCreate(t *testing.T) {
 
 	assert.True(t, gock.IsDone(), "gock.IsDone")
 }
+
+func TestTransactionCreateWithData(t *testing.T) {
+	defer gock.OffAll()
+
+	const objectId = "879"
+	responseBody := getTestDataString(t, "rctransaction.create.responseBody.json")
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": objectId, "type": "STARTUP", "confirmation": true, "data": `{"cloudInit":{"userData":"#cloud-config"}}`}).
+		Reply(200).
+		BodyString(responseBody)
+
+	data := TransactionStartupData{
+		CloudInit: TransactionStartupCloudInit{
+			UserData: "#cloud-config",
+		},
+	}
+	transaction, err := client.TransactionCreateWithData(
+		context.TODO(),
+		TransactionTypeStartup,
+		TransactionObjectTypeDevice,
+		objectId,
+		true,
+		data)
+	assertGockNoUnmatchedRequests(t)
+	require.NoError(t, err, "TransactionCreateWithData error")
+
+	assert.Equal(t, "141414", transaction.TransactionId, "TransactionId")
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+}
+
+func TestEncodeTransactionData(t *testing.T) {
+	encoded, err := encodeTransactionData(nil)
+	require.NoError(t, err, "nil")
+	assert.Equal(t, "", encoded, "nil")
+
+	encoded, err = encodeTransactionData("raw data")
+	require.NoError(t, err, "string")
+	assert.Equal(t, "raw data", encoded, "string")
+
+	encoded, err = encodeTransactionData(TransactionOpenVNCData{AllowedIP: netip.MustParseAddr("198.51.100.7")})
+	require.NoError(t, err, "TransactionOpenVNCData")
+	assert.Equal(t, "198.51.100.7", encoded, "TransactionOpenVNCData")
+
+	_, err = encodeTransactionData(TransactionOpenVNCData{})
+	assert.Error(t, err, "TransactionOpenVNCData without AllowedIP")
+
+	encoded, err = encodeTransactionData(TransactionCancelData{TransactionId: "141414"})
+	require.NoError(t, err, "TransactionCancelData")
+	assert.Equal(t, "141414", encoded, "TransactionCancelData")
+
+	_, err = encodeTransactionData(TransactionCancelData{})
+	assert.Error(t, err, "TransactionCancelData without TransactionId")
+
+	encoded, err = encodeTransactionData(map[string]int{"size": 20})
+	require.NoError(t, err, "map")
+	assert.Equal(t, `{"size":20}`, encoded, "map")
+}
+
+func TestTransactionCreateWithApproval(t *testing.T) {
+	const objectId = "879"
+	const createResponseBody = `{"data":{"objType":"DEVICE","type":"SHUTDOWN","data":null,"confirmationRequired":true,"confirmationText":"The server will be powered off","rcTransactionId":141418,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+	const confirmResponseBody = `{"data":{"objType":"DEVICE","type":"SHUTDOWN","data":null,"confirmationRequired":false,"confirmationText":"","rcTransactionId":141419,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+	const cancelResponseBody = `{"data":{"objType":"DEVICE","type":"CANCEL","data":"141418","confirmationRequired":false,"confirmationText":"","rcTransactionId":141420,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+
+	for _, approved := range []bool{true, false} {
+		t.Run(fmt.Sprintf("approved=%t", approved), func(t *testing.T) {
+			defer gock.OffAll()
+
+			client := getTestClient(t)
+
+			gock.New("https://api.rackcorp.net").
+				Post("/api/v2.9/rctransaction").
+				JSON(map[string]any{"objType": "DEVICE", "objId": objectId, "type": "SHUTDOWN", "confirmation": false, "data": ""}).
+				Reply(200).
+				BodyString(createResponseBody)
+			if approved {
+				gock.New("https://api.rackcorp.net").
+					Post("/api/v2.9/rctransaction").
+					JSON(map[string]any{"objType": "DEVICE", "objId": objectId, "type": "SHUTDOWN", "confirmation": true, "data": ""}).
+					Reply(200).
+					BodyString(confirmResponseBody)
+			}
+			// the original is cancelled when it is rejected, and when it has been confirmed by creating it again
+			gock.New("https://api.rackcorp.net").
+				Post("/api/v2.9/rctransaction").
+				JSON(map[string]any{"objType": "DEVICE", "objId": objectId, "type": "CANCEL", "confirmation": true, "data": "141418"}).
+				Reply(200).
+				BodyString(cancelResponseBody)
+
+			var confirmationText string
+			transaction, err := client.TransactionCreateWithApproval(
+				context.TODO(),
+				TransactionTypeShutdown,
+				TransactionObjectTypeDevice,
+				objectId,
+				nil,
+				func(ctx context.Context, transaction *Transaction) (bool, error) {
+					confirmationText = transaction.ConfirmationText
+					return approved, nil
+				})
+			assertGockNoUnmatchedRequests(t)
+			assert.True(t, gock.IsDone(), "gock.IsDone")
+			assert.Equal(t, "The server will be powered off", confirmationText, "ConfirmationText")
+
+			if approved {
+				require.NoError(t, err, "TransactionCreateWithApproval error")
+				assert.Equal(t, "141419", transaction.TransactionId, "TransactionId")
+				assert.False(t, transaction.ConfirmationRequired, "ConfirmationRequired")
+			} else {
+				require.True(t, errors.Is(err, ErrTransactionNotApproved), "TransactionCreateWithApproval error: %v", err)
+				assert.Nil(t, transaction, "transaction")
+			}
+		})
+	}
+}
+
+func TestTransactionCreateWithApprovalCancelledContext(t *testing.T) {
+	defer gock.OffAll()
+
+	const objectId = "879"
+	const createResponseBody = `{"data":{"objType":"DEVICE","type":"SHUTDOWN","data":null,"confirmationRequired":true,"confirmationText":"The server will be powered off","rcTransactionId":141418,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+	const cancelResponseBody = `{"data":{"objType":"DEVICE","type":"CANCEL","data":"141418","confirmationRequired":false,"confirmationText":"","rcTransactionId":141420,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": objectId, "type": "SHUTDOWN", "confirmation": false, "data": ""}).
+		Reply(200).
+		BodyString(createResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": objectId, "type": "CANCEL", "confirmation": true, "data": "141418"}).
+		Reply(200).
+		BodyString(cancelResponseBody)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	_, err := client.TransactionCreateWithApproval(
+		ctx,
+		TransactionTypeShutdown,
+		TransactionObjectTypeDevice,
+		objectId,
+		nil,
+		func(ctx context.Context, transaction *Transaction) (bool, error) {
+			cancel()
+			return false, ctx.Err()
+		})
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone: the pending transaction must be cancelled")
+	assert.True(t, errors.Is(err, context.Canceled), "TransactionCreateWithApproval error: %v", err)
+}
+
+func TestTransactionConfirm(t *testing.T) {
+	defer gock.OffAll()
+
+	const getResponseBody = `{"rcTransaction":{"rcTransactionId":"141418","objType":"DEVICE","objId":"879","method":"SHUTDOWN","data":"","status":"PENDING"},"code":"OK","message":"Transaction lookup successful"}`
+	const confirmResponseBody = `{"data":{"objType":"DEVICE","type":"SHUTDOWN","data":null,"confirmationRequired":false,"confirmationText":"","rcTransactionId":141419,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/rest/v2.9/json.php").
+		AddMatcher(gockMatchLegacyCommand("rctransaction.get", nil)).
+		Reply(200).
+		BodyString(getResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "SHUTDOWN", "confirmation": true, "data": ""}).
+		Reply(200).
+		BodyString(confirmResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "CANCEL", "confirmation": true, "data": "141418"}).
+		Reply(200).
+		BodyString(transactionCancelResponseBody)
+
+	transaction, err := client.TransactionConfirm(context.TODO(), "141418")
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone")
+	require.NoError(t, err, "TransactionConfirm error")
+	assert.Equal(t, "141419", transaction.TransactionId, "TransactionId")
+}
+
+func TestTransactionConfirmFailure(t *testing.T) {
+	const getResponseBody = `{"rcTransaction":{"rcTransactionId":"141418","objType":"DEVICE","objId":"879","method":"SHUTDOWN","data":"","status":"PENDING"},"code":"OK","message":"Transaction lookup successful"}`
+	const confirmResponseBody = `{"data":{"objType":"DEVICE","type":"SHUTDOWN","data":null,"confirmationRequired":false,"confirmationText":"","rcTransactionId":141419,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+
+	gockGet := func() {
+		gock.New("https://api.rackcorp.net").
+			Post("/api/rest/v2.9/json.php").
+			AddMatcher(gockMatchLegacyCommand("rctransaction.get", nil)).
+			Reply(200).
+			BodyString(getResponseBody)
+	}
+
+	t.Run("create fails", func(t *testing.T) {
+		defer gock.OffAll()
+		client := getTestClient(t)
+
+		gockGet()
+		gock.New("https://api.rackcorp.net").
+			Post("/api/v2.9/rctransaction").
+			JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "SHUTDOWN", "confirmation": true, "data": ""}).
+			Reply(200).
+			BodyString(`{"code":"FAULT","message":"Device is locked"}`)
+
+		transaction, err := client.TransactionConfirm(context.TODO(), "141418")
+		assertGockNoUnmatchedRequests(t)
+		assert.True(t, gock.IsDone(), "gock.IsDone: the original must not be cancelled")
+		assert.Nil(t, transaction, "transaction")
+		require.Error(t, err, "TransactionConfirm error")
+		assert.Contains(t, err.Error(), "transaction id '141418', which is still pending", "TransactionConfirm error")
+	})
+
+	t.Run("cancel fails", func(t *testing.T) {
+		defer gock.OffAll()
+		client := getTestClient(t)
+
+		gockGet()
+		gock.New("https://api.rackcorp.net").
+			Post("/api/v2.9/rctransaction").
+			JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "SHUTDOWN", "confirmation": true, "data": ""}).
+			Reply(200).
+			BodyString(confirmResponseBody)
+		gock.New("https://api.rackcorp.net").
+			Post("/api/v2.9/rctransaction").
+			JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "CANCEL", "confirmation": true, "data": "141418"}).
+			Reply(200).
+			BodyString(`{"code":"FAULT","message":"Transaction cannot be cancelled"}`)
+
+		transaction, err := client.TransactionConfirm(context.TODO(), "141418")
+		assertGockNoUnmatchedRequests(t)
+		assert.True(t, gock.IsDone(), "gock.IsDone")
+		require.NotNil(t, transaction, "transaction is returned with the cancel error")
+		assert.Equal(t, "141419", transaction.TransactionId, "TransactionId")
+		require.Error(t, err, "TransactionConfirm error")
+		assert.Contains(t, err.Error(), "the original is still pending", "TransactionConfirm error")
+	})
+}
+
+func TestTransactionCreateWithApprovalConfirmFails(t *testing.T) {
+	defer gock.OffAll()
+
+	const createResponseBody = `{"data":{"objType":"DEVICE","type":"SHUTDOWN","data":null,"confirmationRequired":true,"confirmationText":"The server will be powered off","rcTransactionId":141418,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`
+
+	client := getTestClient(t)
+
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "SHUTDOWN", "confirmation": false, "data": ""}).
+		Reply(200).
+		BodyString(createResponseBody)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "SHUTDOWN", "confirmation": true, "data": ""}).
+		Reply(200).
+		BodyString(`{"code":"FAULT","message":"Device is locked"}`)
+	gock.New("https://api.rackcorp.net").
+		Post("/api/v2.9/rctransaction").
+		JSON(map[string]any{"objType": "DEVICE", "objId": "879", "type": "CANCEL", "confirmation": true, "data": "141418"}).
+		Reply(200).
+		BodyString(transactionCancelResponseBody)
+
+	transaction, err := client.TransactionCreateWithApproval(context.TODO(), TransactionTypeShutdown, TransactionObjectTypeDevice, "879", nil,
+		func(ctx context.Context, transaction *Transaction) (bool, error) { return true, nil })
+	assertGockNoUnmatchedRequests(t)
+	assert.True(t, gock.IsDone(), "gock.IsDone: the pending transaction must be cancelled")
+	assert.Nil(t, transaction, "transaction")
+	assert.Error(t, err, "TransactionCreateWithApproval error")
+}
+
+const transactionCancelResponseBody = `{"data":{"objType":"DEVICE","type":"CANCEL","data":"141418","confirmationRequired":false,"confirmationText":"","rcTransactionId":141420,"objId":"879"},"code":"OK","message":"Transaction successfully created"}`